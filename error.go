@@ -0,0 +1,64 @@
+package sqlparser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrorWithPos is the error returned when parsing fails. Besides the message, it knows the SQL that failed to parse
+// and the position at which it failed.
+type ErrorWithPos struct {
+	msg string
+	err error
+	sql string
+	pos int
+}
+
+func (e *ErrorWithPos) Error() string {
+	return e.msg
+}
+
+// Unwrap returns the underlying error, e.g. the context's error when parsing is aborted by ParseContext
+func (e *ErrorWithPos) Unwrap() error {
+	return e.err
+}
+
+// Pos returns the byte offset in the SQL at which parsing failed
+func (e *ErrorWithPos) Pos() int {
+	return e.pos
+}
+
+// Line returns the 1-based line of the SQL at which parsing failed
+func (e *ErrorWithPos) Line() int {
+	return strings.Count(e.sql[:e.pos], "\n") + 1
+}
+
+// Column returns the 1-based column (in bytes) of the SQL at which parsing failed, within its line
+func (e *ErrorWithPos) Column() int {
+	return e.pos - e.lineStart() + 1
+}
+
+// PrintPosError prints the line of the SQL at which parsing failed, a caret under the column at which it failed,
+// and the error message. Parsing never prints anything itself, so it's up to the caller to call it, e.g. with
+// os.Stderr.
+func (e *ErrorWithPos) PrintPosError(w io.Writer) {
+	line := e.sql[e.lineStart():]
+	if lineEnd := strings.IndexByte(line, '\n'); lineEnd != -1 {
+		line = line[:lineEnd]
+	}
+	// Tabs are kept as they are, so that the caret lines up with the line above regardless of the tab width
+	indent := []byte(line[:e.Column()-1])
+	for i := range indent {
+		if indent[i] != '\t' {
+			indent[i] = ' '
+		}
+	}
+	fmt.Fprintln(w, strings.TrimSuffix(line, "\r"))
+	fmt.Fprintln(w, string(indent)+"^")
+	fmt.Fprintln(w, e.msg)
+}
+
+func (e *ErrorWithPos) lineStart() int {
+	return strings.LastIndexByte(e.sql[:e.pos], '\n') + 1
+}
@@ -0,0 +1,43 @@
+//go:build go1.18
+// +build go1.18
+
+package sqlparser
+
+import "testing"
+
+// FuzzParse checks that Parse returns an error rather than panicking on any input. Run it with:
+//
+//	go test -fuzz FuzzParse
+func FuzzParse(f *testing.F) {
+	for _, sql := range []string{
+		"SELECT a, COUNT(*) AS c FROM 'b' AS t JOIN c ON t.id = c.id WHERE d = 1 AND NOT (e > 2 OR f IN (1, 'g')) " +
+			"GROUP BY a HAVING c > 1 ORDER BY a DESC NULLS LAST LIMIT 10 OFFSET 5 UNION ALL SELECT a FROM h",
+		"SELECT DISTINCT a FROM s.b WHERE c BETWEEN 1 AND 2 AND d IS NOT NULL AND e LIKE 'f%' AND g <> $1;",
+		"INSERT INTO 'a' (b, c) VALUES ('it''s', -1.5), (NULL, ?) ON DUPLICATE KEY UPDATE b = 'd' RETURNING b",
+		"INSERT INTO 'a' (b) SELECT c FROM 'd' WHERE e = 1",
+		"REPLACE INTO 'a' (b) VALUES ('c\\'d')",
+		"UPDATE 'a' SET b = 'c', d = NULL FROM 'e' WHERE a.f = e.f RETURNING b",
+		"DELETE FROM 'a' USING 'b' WHERE a.c = b.c LIMIT 1",
+		"CREATE TABLE 'a' (b INT NOT NULL PRIMARY KEY, c VARCHAR(255) DEFAULT 'd', e DECIMAL(10, 2))",
+		"DROP TABLE IF EXISTS 'a'",
+		"TRUNCATE TABLE 'a'",
+		"CALL s.p(1, 'a', NULL)",
+		"EXPLAIN ANALYZE SELECT a FROM 'b' -- comment\n/* block */",
+		"SELECT a FROM 'b'; SELECT c FROM 'd'",
+		"SELECT 'a",
+		"SELECT a FROM 'b' WHERE c IN (",
+	} {
+		f.Add(sql)
+	}
+	f.Fuzz(func(t *testing.T, sql string) {
+		_, _ = Parse(sql)
+		_, _ = ParseScript(sql)
+		_, _ = ParseWithOptions(sql, Options{IdentifierQuotes: "`\"", RejectUnquotedTableNames: true})
+		l := NewLexer(sql)
+		for i := 0; i <= len(sql); i++ {
+			if _, err := l.Next(); err != nil {
+				break
+			}
+		}
+	})
+}
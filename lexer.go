@@ -0,0 +1,91 @@
+package sqlparser
+
+import (
+	"fmt"
+	"io"
+)
+
+// TokenKind is the kind of a Token
+type TokenKind int
+
+const (
+	// UnknownToken is the zero value for a TokenKind
+	UnknownToken TokenKind = iota
+	// KeywordToken is a reserved word, e.g. SELECT or GROUP BY
+	KeywordToken
+	// IdentifierToken is a field or table name, which includes qualified names (e.g. users.id) and function calls
+	// (e.g. COUNT(*))
+	IdentifierToken
+	// StringToken is a quoted string, e.g. 'it''s'
+	StringToken
+	// NumberToken is a numeric literal, e.g. -3.14
+	NumberToken
+	// SymbolToken is a punctuation or operator reserved word, e.g. ( or >=
+	SymbolToken
+	// PlaceholderToken is a positional parameter placeholder, e.g. ? or $1
+	PlaceholderToken
+)
+
+// TokenKindString is a string slice with the names of all token kinds in order
+var TokenKindString = []string{
+	"UnknownToken",
+	"KeywordToken",
+	"IdentifierToken",
+	"StringToken",
+	"NumberToken",
+	"SymbolToken",
+	"PlaceholderToken",
+}
+
+func (k TokenKind) String() string {
+	if k < 0 || int(k) >= len(TokenKindString) {
+		return fmt.Sprintf("TokenKind(%d)", k)
+	}
+	return TokenKindString[k]
+}
+
+// Token is a single token of SQL, as peeked by the parser
+type Token struct {
+	Kind  TokenKind
+	Text  string // As written in the SQL, e.g. 'it''s' or group  by
+	Value string // What the parser makes of Text: upper case keywords with single spaces, unquoted strings, e.g. it's or GROUP BY
+	Pos   int    // The byte offset of Text in the SQL
+}
+
+// Lexer splits SQL into the same tokens that the parser peeks, skipping whitespace and comments.
+// Note that the parser peeks some tokens depending on context, e.g. table names, so the tokens are those of the
+// clauses that aren't context dependent.
+type Lexer struct {
+	p parser
+}
+
+// NewLexer returns a Lexer for the given SQL
+func NewLexer(sql string) *Lexer {
+	return NewLexerWithOptions(sql, Options{})
+}
+
+// NewLexerWithOptions returns a Lexer for the given SQL with the given options, e.g. IdentifierQuotes
+func NewLexerWithOptions(sql string, options Options) *Lexer {
+	l := &Lexer{p: parser{sql: sql, options: options}}
+	l.p.popWhitespace()
+	return l
+}
+
+// Next returns the next token, or io.EOF after the last one. If there's no valid token ahead, e.g. an unclosed
+// quoted string, it returns an *ErrorWithPos.
+func (l *Lexer) Next() (Token, error) {
+	if l.p.i >= len(l.p.sql) {
+		return Token{}, io.EOF
+	}
+	if isUnterminatedComment(l.p.sql, l.p.i) {
+		err := fmt.Errorf("unterminated comment")
+		return Token{}, &ErrorWithPos{msg: err.Error(), err: err, sql: l.p.sql, pos: l.p.i}
+	}
+	token, ln := l.p.peekTokenWithLength()
+	if ln == 0 {
+		err := fmt.Errorf("unexpected token")
+		return Token{}, &ErrorWithPos{msg: err.Error(), err: err, sql: l.p.sql, pos: l.p.i}
+	}
+	l.p.popLength(ln)
+	return token, nil
+}
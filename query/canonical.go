@@ -0,0 +1,141 @@
+package query
+
+// Canonical returns a copy of the query that's equal to that of any other query that only differs in how it was built,
+// e.g. when comparing them with reflect.DeepEqual. It normalizes:
+//
+//   - empty slices to nil, e.g. Fields: []string{} to Fields: nil
+//   - empty maps to nil, e.g. Updates: map[string]string{} to Updates: nil
+//
+// It does so everywhere, i.e. also in joins, conditions, WHERE expressions, SELECTed field expressions, column
+// definitions and nested queries. Nothing is sorted, since the order of e.g. Fields or Conditions is significant, and
+// maps already compare equal regardless of their order. Everything else, e.g. each condition's Pos, is kept as is.
+//
+// The query itself isn't modified: the returned one doesn't share slices, maps or nested queries with it.
+func (q Query) Canonical() Query {
+	c := q
+	c.Joins = nil
+	for _, j := range q.Joins {
+		j.On = canonicalConditions(j.On)
+		c.Joins = append(c.Joins, j)
+	}
+	c.Conditions = canonicalConditions(q.Conditions)
+	if q.WhereExpr != nil {
+		whereExpr := q.WhereExpr.canonical()
+		c.WhereExpr = &whereExpr
+	}
+	c.Updates = canonicalStrings(q.Updates)
+	c.UpdateTypes = canonicalOperandTypes(q.UpdateTypes)
+	c.UpdatePairs = append([]UpdatePair(nil), q.UpdatePairs...)
+	c.Inserts = nil
+	for _, row := range q.Inserts {
+		c.Inserts = append(c.Inserts, append([]string(nil), row...))
+	}
+	c.InsertTypes = nil
+	for _, row := range q.InsertTypes {
+		c.InsertTypes = append(c.InsertTypes, append([]OperandType(nil), row...))
+	}
+	if q.InsertSelect != nil {
+		insertSelect := q.InsertSelect.Canonical()
+		c.InsertSelect = &insertSelect
+	}
+	c.Fields = append([]string(nil), q.Fields...)
+	c.FieldExprs = nil
+	for _, e := range q.FieldExprs {
+		c.FieldExprs = append(c.FieldExprs, e.canonical())
+	}
+	c.Aliases = canonicalStrings(q.Aliases)
+	c.GroupBy = append([]string(nil), q.GroupBy...)
+	c.Having = canonicalConditions(q.Having)
+	c.OrderBy = append([]OrderByField(nil), q.OrderBy...)
+	if q.Limit != nil {
+		limit := *q.Limit
+		c.Limit = &limit
+	}
+	if q.Offset != nil {
+		offset := *q.Offset
+		c.Offset = &offset
+	}
+	c.Columns = nil
+	for _, column := range q.Columns {
+		column.TypeParams = append([]int(nil), column.TypeParams...)
+		c.Columns = append(c.Columns, column)
+	}
+	if q.FromSubquery != nil {
+		fromSubquery := q.FromSubquery.Canonical()
+		c.FromSubquery = &fromSubquery
+	}
+	if q.Union != nil {
+		c.Union = &Union{All: q.Union.All, Query: q.Union.Query.Canonical()}
+	}
+	c.Params = append([]Param(nil), q.Params...)
+	c.OnDuplicate = canonicalStrings(q.OnDuplicate)
+	c.OnDuplicateTypes = canonicalOperandTypes(q.OnDuplicateTypes)
+	c.Returning = append([]string(nil), q.Returning...)
+	c.Args = append([]string(nil), q.Args...)
+	c.ArgTypes = append([]OperandType(nil), q.ArgTypes...)
+	c.Using = append([]string(nil), q.Using...)
+	c.UpdateFrom = append([]string(nil), q.UpdateFrom...)
+	return c
+}
+
+func (e WhereExpr) canonical() WhereExpr {
+	c := e
+	c.Children = nil
+	for _, child := range e.Children {
+		c.Children = append(c.Children, child.canonical())
+	}
+	if e.Condition != nil {
+		condition := e.Condition.canonical()
+		c.Condition = &condition
+	}
+	return c
+}
+
+func (e FieldExpr) canonical() FieldExpr {
+	c := e
+	c.Args = nil
+	for _, arg := range e.Args {
+		c.Args = append(c.Args, arg.canonical())
+	}
+	return c
+}
+
+func (c Condition) canonical() Condition {
+	c.Operand2List = append([]string(nil), c.Operand2List...)
+	c.Operand2ListTypes = append([]OperandType(nil), c.Operand2ListTypes...)
+	if c.Subquery != nil {
+		subquery := c.Subquery.Canonical()
+		c.Subquery = &subquery
+	}
+	return c
+}
+
+func canonicalConditions(conditions []Condition) []Condition {
+	var canonical []Condition
+	for _, c := range conditions {
+		canonical = append(canonical, c.canonical())
+	}
+	return canonical
+}
+
+func canonicalStrings(m map[string]string) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	canonical := make(map[string]string, len(m))
+	for k, v := range m {
+		canonical[k] = v
+	}
+	return canonical
+}
+
+func canonicalOperandTypes(m map[string]OperandType) map[string]OperandType {
+	if len(m) == 0 {
+		return nil
+	}
+	canonical := make(map[string]OperandType, len(m))
+	for k, v := range m {
+		canonical[k] = v
+	}
+	return canonical
+}
@@ -0,0 +1,122 @@
+package query
+
+import (
+	"sort"
+	"strings"
+)
+
+// ReferencedColumns returns the sorted, distinct columns referenced anywhere in the query, including nested queries:
+// SELECTed fields, fields in conditions, GROUP BY, ORDER BY and RETURNING fields, INSERTed fields and UPDATEd ones,
+// including those of ON DUPLICATE KEY UPDATE, and the fields their values are computed from. Function calls and
+// arithmetic contribute the columns within them, e.g. a and b for round(a) * b. Qualified columns are returned as
+// written, e.g. users.id.
+//
+// It's not called Columns because that's the field with the column definitions of a CREATE TABLE.
+func (q Query) ReferencedColumns() []string {
+	columns := map[string]bool{}
+	add := func(column string) {
+		if column != "" && column != "*" && !strings.HasSuffix(column, ".*") && !strings.Contains(column, "(") {
+			columns[column] = true
+		}
+	}
+	// A plain field is added as is, e.g. my-col, which is a hyphenated identifier rather than arithmetic
+	addExpression := func(expression string) {
+		if !strings.ContainsAny(expression, "( +*/'") {
+			add(expression)
+			return
+		}
+		for _, column := range expressionColumns(expression) {
+			add(column)
+		}
+	}
+	q.Walk(func(node interface{}) bool {
+		switch n := node.(type) {
+		case *FieldExpr:
+			switch n.Type {
+			case Column, QualifiedColumn:
+				add(n.Text)
+			case Arithmetic:
+				addExpression(n.Text)
+			}
+		case *Condition:
+			if n.Operand1IsField {
+				addExpression(n.Operand1)
+			}
+			if n.Operand2IsField {
+				addExpression(n.Operand2)
+			}
+		case *FieldName:
+			addExpression(n.Name)
+		case *OrderByField:
+			addExpression(n.Field)
+		case *UpdatePair:
+			add(n.Field)
+			if n.Type == OpField || n.Type == OpExpression {
+				addExpression(n.Value)
+			}
+		}
+		return true
+	})
+	referenced := make([]string, 0, len(columns))
+	for c := range columns {
+		referenced = append(referenced, c)
+	}
+	sort.Strings(referenced)
+	return referenced
+}
+
+// expressionColumns returns the columns in an expression, e.g. a and b.c in lower(a) + b.c, skipping quoted strings,
+// numbers, function names, placeholders and the keywords that may be among function arguments
+func expressionColumns(expression string) []string {
+	var columns []string
+	for i := 0; i < len(expression); {
+		switch b := expression[i]; {
+		case b == '\'':
+			i = closingQuote(expression, i) + 1
+		case b == '"' || b == '`':
+			end := closingQuote(expression, i) + 1
+			for end < len(expression) && isColumnByte(expression[end]) {
+				end++ // e.g. "order".id
+			}
+			columns = append(columns, expression[i:end])
+			i = end
+		case isColumnByte(b):
+			end := i
+			for end < len(expression) && isColumnByte(expression[end]) {
+				end++
+			}
+			word := expression[i:end]
+			isPlaceholder := i > 0 && strings.IndexByte("$:@", expression[i-1]) != -1
+			isFunctionName := strings.HasPrefix(strings.TrimLeft(expression[end:], " "), "(")
+			if !isPlaceholder && !isFunctionName && !(b >= '0' && b <= '9') && !expressionKeywords[strings.ToUpper(word)] {
+				columns = append(columns, word)
+			}
+			i = end
+		default:
+			i++
+		}
+	}
+	return columns
+}
+
+// expressionKeywords are the words that may be among function arguments without being columns, e.g. DISTINCT in
+// count(DISTINCT a)
+var expressionKeywords = map[string]bool{"DISTINCT": true, "AS": true, "NULL": true, "TRUE": true, "FALSE": true}
+
+// closingQuote returns the index of the quote that closes the one at openingQuote, or the last index if it's
+// unclosed. A backslash escapes whatever byte follows it, and a doubled quote is parsed as two quoted strings, which
+// is just as well since neither is a column.
+func closingQuote(s string, openingQuote int) int {
+	for i := openingQuote + 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == s[openingQuote] {
+			return i
+		}
+	}
+	return len(s) - 1
+}
+
+func isColumnByte(b byte) bool {
+	return b == '_' || b == '.' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+}
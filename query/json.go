@@ -0,0 +1,134 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MarshalJSON serializes a Type as its name, e.g. "Select"
+func (t Type) MarshalJSON() ([]byte, error) {
+	return marshalEnum(TypeString, int(t))
+}
+
+// UnmarshalJSON deserializes a Type from its name, e.g. "Select"
+func (t *Type) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(TypeString, data)
+	*t = Type(i)
+	return err
+}
+
+// MarshalJSON serializes an Operator as its name, e.g. "Eq"
+func (o Operator) MarshalJSON() ([]byte, error) {
+	return marshalEnum(OperatorString, int(o))
+}
+
+// UnmarshalJSON deserializes an Operator from its name, e.g. "Eq"
+func (o *Operator) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(OperatorString, data)
+	*o = Operator(i)
+	return err
+}
+
+// MarshalJSON serializes an OperandType as its name, e.g. "OpQuoted"
+func (t OperandType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(OperandTypeString, int(t))
+}
+
+// UnmarshalJSON deserializes an OperandType from its name, e.g. "OpQuoted"
+func (t *OperandType) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(OperandTypeString, data)
+	*t = OperandType(i)
+	return err
+}
+
+// MarshalJSON serializes a NullsOrder as its name, e.g. "NullsLast"
+func (n NullsOrder) MarshalJSON() ([]byte, error) {
+	return marshalEnum(NullsOrderString, int(n))
+}
+
+// UnmarshalJSON deserializes a NullsOrder from its name, e.g. "NullsLast"
+func (n *NullsOrder) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(NullsOrderString, data)
+	*n = NullsOrder(i)
+	return err
+}
+
+// MarshalJSON serializes a Direction as its name, e.g. "Desc"
+func (d Direction) MarshalJSON() ([]byte, error) {
+	return marshalEnum(DirectionString, int(d))
+}
+
+// UnmarshalJSON deserializes a Direction from its name, e.g. "Desc"
+func (d *Direction) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(DirectionString, data)
+	*d = Direction(i)
+	return err
+}
+
+// MarshalJSON serializes a JoinType as its name, e.g. "Inner"
+func (t JoinType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(JoinTypeString, int(t))
+}
+
+// UnmarshalJSON deserializes a JoinType from its name, e.g. "Inner"
+func (t *JoinType) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(JoinTypeString, data)
+	*t = JoinType(i)
+	return err
+}
+
+// MarshalJSON serializes a WhereExprType as its name, e.g. "And"
+func (t WhereExprType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(WhereExprTypeString, int(t))
+}
+
+// UnmarshalJSON deserializes a WhereExprType from its name, e.g. "And"
+func (t *WhereExprType) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(WhereExprTypeString, data)
+	*t = WhereExprType(i)
+	return err
+}
+
+// MarshalJSON serializes a ShowKind as its name, e.g. "ShowTables"
+func (k ShowKind) MarshalJSON() ([]byte, error) {
+	return marshalEnum(ShowKindString, int(k))
+}
+
+// UnmarshalJSON deserializes a ShowKind from its name, e.g. "ShowTables"
+func (k *ShowKind) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(ShowKindString, data)
+	*k = ShowKind(i)
+	return err
+}
+
+// MarshalJSON serializes a FieldExprType as its name, e.g. "Column"
+func (t FieldExprType) MarshalJSON() ([]byte, error) {
+	return marshalEnum(FieldExprTypeString, int(t))
+}
+
+// UnmarshalJSON deserializes a FieldExprType from its name, e.g. "Column"
+func (t *FieldExprType) UnmarshalJSON(data []byte) error {
+	i, err := unmarshalEnum(FieldExprTypeString, data)
+	*t = FieldExprType(i)
+	return err
+}
+
+func marshalEnum(names []string, i int) ([]byte, error) {
+	if i < 0 || i >= len(names) {
+		return nil, fmt.Errorf("unknown enum value %d", i)
+	}
+	return json.Marshal(names[i])
+}
+
+func unmarshalEnum(names []string, data []byte) (int, error) {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return 0, err
+	}
+	for i, n := range names {
+		if n == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown enum name %q", name)
+}
@@ -1,14 +1,63 @@
 package query
 
+import "strconv"
+
 // Query represents a parsed query
 type Query struct {
-	Type       Type
-	TableName  string
-	Conditions []Condition
-	Updates    map[string]string
-	Inserts    [][]string
-	Fields     []string // Used for SELECT (i.e. SELECTed field names) and INSERT (INSERTEDed field names)
-	Aliases    map[string]string
+	Type             Type                   `json:"type"`
+	Schema           string                 `json:"schema,omitempty"`
+	TableName        string                 `json:"tableName"`
+	TableAlias       string                 `json:"tableAlias,omitempty"`
+	Joins            []Join                 `json:"joins,omitempty"`
+	Conditions       []Condition            `json:"conditions,omitempty"`
+	WhereExpr        *WhereExpr             `json:"whereExpr,omitempty"` // The grouping of Conditions; only set when the WHERE clause has parens
+	Updates          map[string]string      `json:"updates,omitempty"`
+	UpdateTypes      map[string]OperandType `json:"updateTypes,omitempty"` // The kind of value of each field in Updates
+	UpdatePairs      []UpdatePair           `json:"updatePairs,omitempty"` // The same as Updates, but in order and keeping repeated fields
+	Inserts          [][]string             `json:"inserts,omitempty"`
+	InsertTypes      [][]OperandType        `json:"insertTypes,omitempty"`  // The kind of each value in Inserts
+	InsertSelect     *Query                 `json:"insertSelect,omitempty"` // The SELECT that provides the rows of an INSERT INTO ... SELECT, instead of Inserts
+	Fields           []string               `json:"fields,omitempty"`       // Used for SELECT (i.e. SELECTed field names) and INSERT (INSERTEDed field names)
+	FieldExprs       []FieldExpr            `json:"fieldExprs,omitempty"`   // The structured form of each SELECTed field in Fields
+	Aliases          map[string]string      `json:"aliases,omitempty"`
+	Distinct         bool                   `json:"distinct,omitempty"`
+	GroupBy          []string               `json:"groupBy,omitempty"`
+	Having           []Condition            `json:"having,omitempty"`
+	OrderBy          []OrderByField         `json:"orderBy,omitempty"`
+	Limit            *int                   `json:"limit,omitempty"`            // Maximum number of rows; nil if unset. For "LIMIT 20, 10" it's 10
+	Offset           *int                   `json:"offset,omitempty"`           // Number of rows to skip; nil if unset. For "LIMIT 20, 10" it's 20
+	Top              bool                   `json:"top,omitempty"`              // Whether Limit comes from SQL Server's SELECT TOP n rather than LIMIT
+	Columns          []ColumnDef            `json:"columns,omitempty"`          // Used for CREATE TABLE
+	IfExists         bool                   `json:"ifExists,omitempty"`         // Used for DROP TABLE IF EXISTS
+	Union            *Union                 `json:"union,omitempty"`            // The SELECT that follows UNION [ALL], if any
+	Params           []Param                `json:"params,omitempty"`           // The placeholders in the query, including nested ones, in order
+	OnDuplicate      map[string]string      `json:"onDuplicate,omitempty"`      // The assignments of INSERT ... ON DUPLICATE KEY UPDATE
+	OnDuplicateTypes map[string]OperandType `json:"onDuplicateTypes,omitempty"` // The kind of value of each field in OnDuplicate
+	Returning        []string               `json:"returning,omitempty"`        // The fields of the RETURNING clause of INSERT, UPDATE & DELETE
+	ProcName         string                 `json:"procName,omitempty"`         // Used for CALL, instead of TableName
+	Args             []string               `json:"args,omitempty"`             // The arguments of a CALL
+	ArgTypes         []OperandType          `json:"argTypes,omitempty"`         // The kind of each value in Args
+	Explain          bool                   `json:"explain,omitempty"`          // Whether the query is prefixed with EXPLAIN
+	Analyze          bool                   `json:"analyze,omitempty"`          // Whether the query is prefixed with EXPLAIN ANALYZE
+	Using            []string               `json:"using,omitempty"`            // The tables of DELETE ... USING, besides the one to delete from
+	UpdateFrom       []string               `json:"updateFrom,omitempty"`       // The tables of UPDATE ... FROM, besides the one to update
+	ShowKind         ShowKind               `json:"showKind,omitempty"`         // Used for SHOW, e.g. ShowColumns for SHOW COLUMNS FROM TableName
+	FromSubquery     *Query                 `json:"fromSubquery,omitempty"`     // The derived table a SELECT is FROM, instead of TableName; its alias is TableAlias
+}
+
+// HasWhere reports whether the query has a WHERE clause
+func (q Query) HasWhere() bool {
+	return len(q.Conditions) > 0
+}
+
+// HasJoins reports whether the query JOINs any table
+func (q Query) HasJoins() bool {
+	return len(q.Joins) > 0
+}
+
+// ConditionCount returns the number of conditions in the WHERE clause, i.e. not counting HAVING or ON ones
+func (q Query) ConditionCount() int {
+	return len(q.Conditions)
 }
 
 // Type is the type of SQL query, e.g. SELECT/UPDATE
@@ -25,6 +74,20 @@ const (
 	Insert
 	// Delete represents a DELETE query
 	Delete
+	// CreateTable represents a CREATE TABLE query
+	CreateTable
+	// DropTable represents a DROP TABLE query
+	DropTable
+	// Truncate represents a TRUNCATE [TABLE] query
+	Truncate
+	// Replace represents a REPLACE INTO query, which is parsed like an INSERT
+	Replace
+	// Call represents a CALL query, i.e. a stored procedure invocation
+	Call
+	// Describe represents a DESCRIBE or DESC query, which shows a table's columns
+	Describe
+	// Show represents a SHOW query, whose ShowKind is what it shows
+	Show
 )
 
 // TypeString is a string slice with the names of all types in order
@@ -34,6 +97,13 @@ var TypeString = []string{
 	"Update",
 	"Insert",
 	"Delete",
+	"CreateTable",
+	"DropTable",
+	"Truncate",
+	"Replace",
+	"Call",
+	"Describe",
+	"Show",
 }
 
 // Operator is between operands in a condition
@@ -44,7 +114,7 @@ const (
 	UnknownOperator Operator = iota
 	// Eq -> "="
 	Eq
-	// Ne -> "!="
+	// Ne -> "!=", also parsed from "<>"
 	Ne
 	// Gt -> ">"
 	Gt
@@ -54,29 +124,396 @@ const (
 	Gte
 	// Lte -> "<="
 	Lte
+	// In -> "IN"
+	In
+	// NotIn -> "NOT IN"
+	NotIn
+	// Between -> "BETWEEN", with Operand2 and Operand3 as the lower and upper bounds
+	Between
+	// Like -> "LIKE", with Operand2 as the verbatim pattern (i.e. '%' and '_' are not interpreted)
+	Like
+	// NotLike -> "NOT LIKE"
+	NotLike
+	// IsNull -> "IS NULL", which has no right hand side operand
+	IsNull
+	// IsNotNull -> "IS NOT NULL", which has no right hand side operand
+	IsNotNull
+	// Exists -> "EXISTS", which has neither operand but a Subquery
+	Exists
+	// NotExists -> "NOT EXISTS", which has neither operand but a Subquery
+	NotExists
 )
 
+// operators has the name and SQL symbol of all operators in order
+var operators = []struct{ name, symbol string }{
+	{"UnknownOperator", ""},
+	{"Eq", "="},
+	{"Ne", "!="},
+	{"Gt", ">"},
+	{"Lt", "<"},
+	{"Gte", ">="},
+	{"Lte", "<="},
+	{"In", "IN"},
+	{"NotIn", "NOT IN"},
+	{"Between", "BETWEEN"},
+	{"Like", "LIKE"},
+	{"NotLike", "NOT LIKE"},
+	{"IsNull", "IS NULL"},
+	{"IsNotNull", "IS NOT NULL"},
+	{"Exists", "EXISTS"},
+	{"NotExists", "NOT EXISTS"},
+}
+
 // OperatorString is a string slice with the names of all operators in order
-var OperatorString = []string{
-	"UnknownOperator",
-	"Eq",
-	"Ne",
-	"Gt",
-	"Lt",
-	"Gte",
-	"Lte",
+var OperatorString = func() []string {
+	names := make([]string, len(operators))
+	for i, o := range operators {
+		names[i] = o.name
+	}
+	return names
+}()
+
+// String returns the SQL symbol of the operator, e.g. ">=" for Gte, or its name if it has none, e.g. UnknownOperator
+func (o Operator) String() string {
+	if o < 0 || int(o) >= len(operators) {
+		return "Operator(" + strconv.Itoa(int(o)) + ")"
+	}
+	if operators[o].symbol == "" {
+		return operators[o].name
+	}
+	return operators[o].symbol
+}
+
+// IsUnary reports whether the operator has no right hand side operand, i.e. IS NULL and IS NOT NULL
+func (o Operator) IsUnary() bool {
+	return o == IsNull || o == IsNotNull
+}
+
+// OperandType is the kind of value an operand holds
+type OperandType int
+
+const (
+	// UnknownOperandType is the zero value for an OperandType
+	UnknownOperandType OperandType = iota
+	// OpQuoted is a quoted string literal, e.g. 'a'
+	OpQuoted
+	// OpNumber is an unquoted numeric literal, e.g. 42
+	OpNumber
+	// OpField is a field name
+	OpField
+	// OpList is a parenthesized list of literals, e.g. ('a', 'b'); its values are in Operand2List
+	OpList
+	// OpNull is the NULL literal, whose value is the empty string (unlike '', which is OpQuoted)
+	OpNull
+	// OpExpression is an arithmetic expression kept as its source text, e.g. counter + '1'
+	OpExpression
+	// OpPlaceholder is a positional parameter placeholder, e.g. ? or $1; it's also recorded in the query's Params
+	OpPlaceholder
+	// OpSubquery is a parenthesized SELECT, e.g. (SELECT max(x) FROM b), which is in the condition's Subquery
+	OpSubquery
+)
+
+// OperandTypeString is a string slice with the names of all operand types in order
+var OperandTypeString = []string{
+	"UnknownOperandType",
+	"OpQuoted",
+	"OpNumber",
+	"OpField",
+	"OpList",
+	"OpNull",
+	"OpExpression",
+	"OpPlaceholder",
+	"OpSubquery",
+}
+
+// Direction is the sorting direction of an ORDER BY field
+type Direction int
+
+const (
+	// Asc represents ascending order, which is the default
+	Asc Direction = iota
+	// Desc represents descending order
+	Desc
+)
+
+// DirectionString is a string slice with the names of all directions in order
+var DirectionString = []string{
+	"Asc",
+	"Desc",
+}
+
+// OrderByField is a single field in an ORDER BY clause
+type OrderByField struct {
+	// Field is the field name to order by; it's empty when ordering by Ordinal
+	Field string `json:"field"`
+	// Ordinal is the 1-based position of the SELECTed field to order by, e.g. 2 in ORDER BY 2; it's 0 if unset
+	Ordinal int `json:"ordinal,omitempty"`
+	// Direction is either ascending or descending
+	Direction Direction `json:"direction"`
+	// Nulls is whether NULLs go first or last, if specified with NULLS FIRST or NULLS LAST
+	Nulls NullsOrder `json:"nulls,omitempty"`
+}
+
+// NullsOrder is where the NULLs of an ORDER BY field go
+type NullsOrder int
+
+const (
+	// UnspecifiedNullsOrder is the zero value for a NullsOrder, which leaves it up to the database
+	UnspecifiedNullsOrder NullsOrder = iota
+	// NullsFirst represents NULLS FIRST
+	NullsFirst
+	// NullsLast represents NULLS LAST
+	NullsLast
+)
+
+// NullsOrderString is a string slice with the names of all nulls orders in order
+var NullsOrderString = []string{
+	"UnspecifiedNullsOrder",
+	"NullsFirst",
+	"NullsLast",
+}
+
+// ShowKind is what a SHOW query shows
+type ShowKind int
+
+const (
+	// UnknownShowKind is the zero value for a ShowKind
+	UnknownShowKind ShowKind = iota
+	// ShowTables represents SHOW TABLES
+	ShowTables
+	// ShowColumns represents SHOW COLUMNS FROM a table
+	ShowColumns
+)
+
+// ShowKindString is a string slice with the names of all show kinds in order
+var ShowKindString = []string{
+	"UnknownShowKind",
+	"ShowTables",
+	"ShowColumns",
+}
+
+// FieldExprType is the kind of a SELECTed field
+type FieldExprType int
+
+const (
+	// UnknownFieldExprType is the type of fields that aren't any of the other types, e.g. count(DISTINCT a)
+	UnknownFieldExprType FieldExprType = iota
+	// Column is a plain column name, e.g. id, or *
+	Column
+	// QualifiedColumn is a column name qualified with its table, e.g. users.id or u.*
+	QualifiedColumn
+	// FunctionCall is a function call, e.g. substring(col, 0, 8)
+	FunctionCall
+	// Literal is a quoted string, a number or NULL
+	Literal
+	// Arithmetic is an arithmetic expression, e.g. price * quantity, which is only kept as written
+	Arithmetic
+)
+
+// FieldExprTypeString is a string slice with the names of all field types in order
+var FieldExprTypeString = []string{
+	"UnknownFieldExprType",
+	"Column",
+	"QualifiedColumn",
+	"FunctionCall",
+	"Literal",
+	"Arithmetic",
+}
+
+// FieldExpr is the structured form of a SELECTed field
+type FieldExpr struct {
+	// Type determines which of the other fields are set, besides Text
+	Type FieldExprType `json:"type"`
+	// Text is the field as written in the query, e.g. substring(col, 0, 8)
+	Text string `json:"text"`
+	// Table is the table (and maybe schema) that qualifies a QualifiedColumn, e.g. public.users
+	Table string `json:"table,omitempty"`
+	// Name is the name of a Column, QualifiedColumn or FunctionCall
+	Name string `json:"name,omitempty"`
+	// Args are the arguments of a FunctionCall
+	Args []FieldExpr `json:"args,omitempty"`
+	// Value is the value of a Literal, unquoted
+	Value string `json:"value,omitempty"`
+	// ValueType is the kind of value of a Literal
+	ValueType OperandType `json:"valueType,omitempty"`
+}
+
+// UpdatePair is a single assignment in the SET clause of an UPDATE, e.g. a = '1'
+type UpdatePair struct {
+	// Field is the field being assigned
+	Field string `json:"field"`
+	// Value is the value assigned to Field
+	Value string `json:"value"`
+	// Type is the kind of value of Value
+	Type OperandType `json:"type"`
+}
+
+// Param is a positional parameter placeholder of a prepared statement
+type Param struct {
+	// Index is the 1-based position of the parameter: N for $N, or the order of appearance among ? placeholders
+	Index int `json:"index"`
+	// Placeholder is the placeholder as written, i.e. ? or $N
+	Placeholder string `json:"placeholder"`
+}
+
+// Union is a SELECT combined with the one before it, e.g. the second SELECT in SELECT a FROM b UNION SELECT a FROM c
+type Union struct {
+	// All is set by UNION ALL, which keeps duplicate rows
+	All bool `json:"all,omitempty"`
+	// Query is the SELECT after UNION [ALL], which may itself be followed by another UNION
+	Query Query `json:"query"`
+}
+
+// ColumnDef is a column definition in a CREATE TABLE query, e.g. id INT NOT NULL
+type ColumnDef struct {
+	// Name is the column name
+	Name string `json:"name"`
+	// Type is the column type as written, without its length or precision, e.g. VARCHAR
+	Type string `json:"type"`
+	// TypeParams are the length or precision of the type, e.g. [10, 2] for DECIMAL(10, 2)
+	TypeParams []int `json:"typeParams,omitempty"`
+	// NotNull is set by the NOT NULL modifier
+	NotNull bool `json:"notNull,omitempty"`
+	// PrimaryKey is set by the PRIMARY KEY modifier
+	PrimaryKey bool `json:"primaryKey,omitempty"`
+	// Default is the value of the DEFAULT modifier, if any
+	Default string `json:"default,omitempty"`
+	// DefaultType is the kind of value of Default; it's UnknownOperandType if there's no DEFAULT modifier
+	DefaultType OperandType `json:"defaultType,omitempty"`
 }
 
-// Condition is a single boolean condition in a WHERE clause
+// JoinType is the type of a JOIN, e.g. INNER
+type JoinType int
+
+const (
+	// UnknownJoinType is the zero value for a JoinType
+	UnknownJoinType JoinType = iota
+	// Inner represents an INNER JOIN, which is also what a plain JOIN is
+	Inner
+	// Left represents a LEFT [OUTER] JOIN
+	Left
+	// Right represents a RIGHT [OUTER] JOIN
+	Right
+	// Full represents a FULL [OUTER] JOIN
+	Full
+)
+
+// JoinTypeString is a string slice with the names of all join types in order
+var JoinTypeString = []string{
+	"UnknownJoinType",
+	"Inner",
+	"Left",
+	"Right",
+	"Full",
+}
+
+// Join is a table joined to the one in the FROM clause, e.g. INNER JOIN 'b' ON a.id = b.a_id
+type Join struct {
+	// Type is the type of join, e.g. Inner
+	Type JoinType `json:"type"`
+	// Schema is the joined table's schema, if it's qualified with one
+	Schema string `json:"schema,omitempty"`
+	// TableName is the joined table's name
+	TableName string `json:"tableName"`
+	// TableAlias is the joined table's alias, if any
+	TableAlias string `json:"tableAlias,omitempty"`
+	// On are the conditions in the ON clause
+	On []Condition `json:"on"`
+}
+
+// Condition is a single boolean condition in a WHERE, HAVING or ON clause
 type Condition struct {
 	// Operand1 is the left hand side operand
-	Operand1 string
+	Operand1 string `json:"operand1"`
 	// Operand1IsField determines if Operand1 is a literal or a field name
-	Operand1IsField bool
+	Operand1IsField bool `json:"operand1IsField"`
 	// Operator is e.g. "=", ">"
-	Operator Operator
-	// Operand1 is the right hand side operand
-	Operand2 string
+	Operator Operator `json:"operator"`
+	// Operand2 is the right hand side operand
+	Operand2 string `json:"operand2"`
 	// Operand2IsField determines if Operand2 is a literal or a field name
-	Operand2IsField bool
+	Operand2IsField bool `json:"operand2IsField"`
+	// Operand2Type determines the kind of value Operand2 (or Operand2List) holds
+	Operand2Type OperandType `json:"operand2Type"`
+	// Operand2List is the right hand side list of values for the IN & NOT IN operators
+	Operand2List []string `json:"operand2List,omitempty"`
+	// Operand2ListTypes determines the kind of each value in Operand2List, e.g. OpNumber for 1 in a IN (1, 'b')
+	Operand2ListTypes []OperandType `json:"operand2ListTypes,omitempty"`
+	// Operand3 is the upper bound for the BETWEEN operator, whose lower bound is Operand2
+	Operand3 string `json:"operand3,omitempty"`
+	// Operand3Type determines the kind of value Operand3 holds
+	Operand3Type OperandType `json:"operand3Type,omitempty"`
+	// OrWithNext determines if this condition is OR'ed with the next one, rather than AND'ed
+	OrWithNext bool `json:"orWithNext"`
+	// Negated determines if this condition is preceded by NOT, e.g. NOT a = '1'
+	Negated bool `json:"negated,omitempty"`
+	// Collation is the collation the operands are compared with, e.g. utf8_bin in a = 'b' COLLATE utf8_bin
+	Collation string `json:"collation,omitempty"`
+	// Escape is the escape character of the LIKE and NOT LIKE pattern, unescaped, e.g. ! in a LIKE 'b!_%' ESCAPE '!',
+	// or \ in a LIKE 'b\_%' ESCAPE '\'; empty if there's no ESCAPE
+	Escape string `json:"escape,omitempty"`
+	// Pos is the byte offset of Operand1 in the parsed SQL, e.g. to point at the condition in an editor. For EXISTS
+	// and NOT EXISTS, it's the offset of EXISTS.
+	Pos int `json:"pos,omitempty"`
+	// Subquery is the SELECT of EXISTS and NOT EXISTS, or the right hand side operand if Operand2Type is OpSubquery,
+	// e.g. the one in a IN (SELECT id FROM b)
+	Subquery *Query `json:"subquery,omitempty"`
+}
+
+// WhereExprType is the type of a node in a WhereExpr tree
+type WhereExprType int
+
+const (
+	// UnknownWhereExprType is the zero value for a WhereExprType
+	UnknownWhereExprType WhereExprType = iota
+	// And represents its children AND'ed together
+	And
+	// Or represents its children OR'ed together
+	Or
+	// Group represents its only child wrapped in parens
+	Group
+	// Leaf represents a single condition
+	Leaf
+)
+
+// WhereExprTypeString is a string slice with the names of all WhereExpr types in order
+var WhereExprTypeString = []string{
+	"UnknownWhereExprType",
+	"And",
+	"Or",
+	"Group",
+	"Leaf",
+}
+
+// WhereExpr is a node in the expression tree of a WHERE clause, which unlike the flat Conditions slice can express
+// conditions grouped with parens, e.g. (a = '1' OR b = '2') AND c = '3'. The conditions in its leaves are the same
+// as those in Conditions, in the same order.
+type WhereExpr struct {
+	// Type determines which of the other fields are set
+	Type WhereExprType `json:"type"`
+	// Children are the operands of an And or Or node, or the only operand of a Group node
+	Children []WhereExpr `json:"children,omitempty"`
+	// Condition is the condition of a Leaf node
+	Condition *Condition `json:"condition,omitempty"`
+	// Negated determines if a Group node is preceded by NOT, e.g. NOT (a = '1' OR b = '2')
+	Negated bool `json:"negated,omitempty"`
+}
+
+// OrGroups splits conditions into the groups that are OR'ed together, following the standard SQL precedence
+// where AND binds tighter than OR. Conditions within each group are AND'ed together.
+// e.g. "a = '1' AND b = '2' OR c = '3'" yields [[a = '1', b = '2'], [c = '3']]
+func OrGroups(conditions []Condition) [][]Condition {
+	groups := [][]Condition{}
+	group := []Condition{}
+	for _, c := range conditions {
+		group = append(group, c)
+		if c.OrWithNext {
+			groups = append(groups, group)
+			group = []Condition{}
+		}
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	return groups
 }
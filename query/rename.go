@@ -0,0 +1,165 @@
+package query
+
+import "strings"
+
+// RenameTable renames the table old to new wherever the query refers to it, including nested queries: the table the
+// query is on, JOINed tables, the tables of USING and UPDATE ... FROM, and the qualifier of columns, e.g. old.id, in
+// SELECTed fields, conditions, assignments (including ON DUPLICATE KEY UPDATE ones), GROUP BY, ORDER BY and
+// RETURNING. Tables are matched by name whatever their schema, which is kept unless new is qualified with one, e.g.
+// public.accounts, and aliases are kept too. Columns within function calls or expressions, e.g. count(old.id), are
+// kept as written. It does nothing if the query doesn't refer to old.
+func (q *Query) RenameTable(old, new string) {
+	r := tableRenamer{old: old, new: new}
+	if i := strings.LastIndexByte(new, '.'); i != -1 {
+		r.newSchema, r.newName = new[:i], new[i+1:]
+	} else {
+		r.newName = new
+	}
+	q.Walk(func(node interface{}) bool {
+		switch n := node.(type) {
+		case *Query:
+			r.renameQuery(n)
+		case *Join:
+			if n.TableName == old {
+				n.TableName = r.newName
+				if r.newSchema != "" {
+					n.Schema = r.newSchema
+				}
+			}
+		case *Condition:
+			r.renameCondition(n)
+		case *OrderByField:
+			n.Field = r.column(n.Field)
+		}
+		return true
+	})
+}
+
+type tableRenamer struct {
+	old, new           string
+	newSchema, newName string // The parts of new, e.g. public and accounts for public.accounts
+}
+
+// table renames a table name that may be qualified with its schema, e.g. public.users. Its schema is kept unless new
+// is qualified with one.
+func (r tableRenamer) table(name string) string {
+	i := strings.LastIndexByte(name, '.')
+	if name[i+1:] != r.old {
+		return name
+	}
+	if r.newSchema != "" {
+		return r.new
+	}
+	return name[:i+1] + r.new
+}
+
+// column renames the table that qualifies a column, e.g. users.id, unless it's within a function call or expression
+func (r tableRenamer) column(column string) string {
+	i := strings.LastIndexByte(column, '.')
+	if i == -1 || strings.ContainsAny(column, "( ") {
+		return column
+	}
+	return r.table(column[:i]) + column[i:]
+}
+
+func (r tableRenamer) renameQuery(q *Query) {
+	if q.TableName == r.old {
+		q.TableName = r.newName
+		if r.newSchema != "" {
+			q.Schema = r.newSchema
+		}
+	}
+	for i := range q.Using {
+		q.Using[i] = r.table(q.Using[i])
+	}
+	for i := range q.UpdateFrom {
+		q.UpdateFrom[i] = r.table(q.UpdateFrom[i])
+	}
+	for i, f := range q.Fields {
+		if len(q.FieldExprs) == len(q.Fields) && q.FieldExprs[i].Type != QualifiedColumn {
+			continue // e.g. a quoted string, which isn't a column even if it has a dot
+		}
+		renamed := r.column(f)
+		if renamed == f {
+			continue
+		}
+		if alias, ok := q.Aliases[f]; ok {
+			delete(q.Aliases, f)
+			q.Aliases[renamed] = alias
+		}
+		q.Fields[i] = renamed
+	}
+	for i := range q.FieldExprs {
+		if e := &q.FieldExprs[i]; e.Type == QualifiedColumn {
+			e.Table = r.table(e.Table)
+			e.Text = r.column(e.Text)
+		}
+	}
+	for i := range q.GroupBy {
+		q.GroupBy[i] = r.column(q.GroupBy[i])
+	}
+	for i := range q.Returning {
+		q.Returning[i] = r.column(q.Returning[i])
+	}
+	for i := range q.UpdatePairs {
+		u := &q.UpdatePairs[i]
+		u.Field = r.column(u.Field)
+		if u.Type == OpField {
+			u.Value = r.column(u.Value)
+		}
+	}
+	q.Updates, q.UpdateTypes = r.renameAssignments(q.Updates, q.UpdateTypes)
+	q.OnDuplicate, q.OnDuplicateTypes = r.renameAssignments(q.OnDuplicate, q.OnDuplicateTypes)
+	walked := map[*Condition]bool{}
+	for i := range q.Conditions {
+		walked[&q.Conditions[i]] = true
+	}
+	r.renameWhereExpr(q.WhereExpr, walked)
+}
+
+// renameAssignments returns assignments, e.g. the SET ones of an UPDATE, with their fields renamed, and their values
+// too if they're fields. Types may be nil, e.g. for a query that was built by hand, and are returned as such.
+func (r tableRenamer) renameAssignments(values map[string]string, types map[string]OperandType) (map[string]string, map[string]OperandType) {
+	if values == nil {
+		return values, types
+	}
+	renamedValues := make(map[string]string, len(values))
+	var renamedTypes map[string]OperandType
+	if types != nil {
+		renamedTypes = make(map[string]OperandType, len(types))
+	}
+	for f, v := range values {
+		t, hasType := types[f]
+		if t == OpField {
+			v = r.column(v)
+		}
+		renamedValues[r.column(f)] = v
+		if hasType {
+			renamedTypes[r.column(f)] = t
+		}
+	}
+	return renamedValues, renamedTypes
+}
+
+// renameWhereExpr renames the conditions of a WHERE expression that Walk didn't visit, i.e. that aren't the same as
+// the query's Conditions, e.g. because the query was unmarshalled, so that none is renamed twice
+func (r tableRenamer) renameWhereExpr(e *WhereExpr, walked map[*Condition]bool) {
+	if e == nil {
+		return
+	}
+	if e.Condition != nil && !walked[e.Condition] {
+		r.renameCondition(e.Condition)
+	}
+	for i := range e.Children {
+		r.renameWhereExpr(&e.Children[i], walked)
+	}
+}
+
+func (r tableRenamer) renameCondition(c *Condition) {
+	if c.Operand1IsField {
+		c.Operand1 = r.column(c.Operand1)
+	}
+	if c.Operand2IsField {
+		c.Operand2 = r.column(c.Operand2)
+	}
+}
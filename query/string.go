@@ -0,0 +1,347 @@
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// String renders the query back into canonical SQL, i.e. a query that parses into an equal Query.
+// It's not necessarily byte-identical to the originally parsed SQL.
+func (q Query) String() string {
+	var sb strings.Builder
+	if q.Analyze {
+		sb.WriteString("EXPLAIN ANALYZE ")
+	} else if q.Explain {
+		sb.WriteString("EXPLAIN ")
+	}
+	switch q.Type {
+	case Select:
+		sb.WriteString("SELECT ")
+		if q.Distinct {
+			sb.WriteString("DISTINCT ")
+		}
+		if q.Top && q.Limit != nil {
+			sb.WriteString(fmt.Sprintf("TOP %d ", *q.Limit))
+		}
+		fields := make([]string, len(q.Fields))
+		for i, f := range q.Fields {
+			fields[i] = f
+			if len(q.FieldExprs) == len(q.Fields) {
+				fields[i] = q.FieldExprs[i].Text // Unlike Fields, it keeps quoted strings quoted
+			}
+			if alias, ok := q.Aliases[f]; ok {
+				fields[i] += " AS " + alias
+			}
+		}
+		sb.WriteString(strings.Join(fields, ", "))
+		if q.FromSubquery != nil {
+			sb.WriteString(" FROM (" + q.FromSubquery.String() + ")")
+		} else {
+			sb.WriteString(" FROM " + tableString(q.Schema, q.TableName))
+		}
+		if q.TableAlias != "" {
+			sb.WriteString(" AS " + q.TableAlias)
+		}
+		for _, j := range q.Joins {
+			sb.WriteString(" " + joinTypeKeywords[j.Type] + " " + tableString(j.Schema, j.TableName))
+			if j.TableAlias != "" {
+				sb.WriteString(" AS " + j.TableAlias)
+			}
+			sb.WriteString(" ON " + conditionsString(j.On))
+		}
+	case Insert, Replace:
+		if q.Type == Replace {
+			sb.WriteString("REPLACE INTO " + tableString(q.Schema, q.TableName))
+		} else {
+			sb.WriteString("INSERT INTO " + tableString(q.Schema, q.TableName))
+		}
+		sb.WriteString(" (" + strings.Join(q.Fields, ", ") + ")")
+		if q.InsertSelect != nil {
+			sb.WriteString(" " + q.InsertSelect.String())
+			break
+		}
+		sb.WriteString(" VALUES ")
+		rows := make([]string, len(q.Inserts))
+		for i, row := range q.Inserts {
+			var rowTypes []OperandType
+			if i < len(q.InsertTypes) {
+				rowTypes = q.InsertTypes[i]
+			}
+			values := make([]string, len(row))
+			for j, v := range row {
+				values[j] = operandString(v, operandTypeAt(rowTypes, j))
+			}
+			rows[i] = "(" + strings.Join(values, ", ") + ")"
+		}
+		sb.WriteString(strings.Join(rows, ", "))
+		if q.OnDuplicate != nil {
+			sb.WriteString(" ON DUPLICATE KEY UPDATE " + updatesString(q.OnDuplicate, q.OnDuplicateTypes))
+		}
+	case Update:
+		sb.WriteString("UPDATE " + tableString(q.Schema, q.TableName) + " SET ")
+		if len(q.UpdatePairs) == 0 {
+			sb.WriteString(updatesString(q.Updates, q.UpdateTypes))
+		} else {
+			updates := make([]string, len(q.UpdatePairs))
+			for i, u := range q.UpdatePairs {
+				updates[i] = u.Field + " = " + operandString(u.Value, u.Type)
+			}
+			sb.WriteString(strings.Join(updates, ", "))
+		}
+		if len(q.UpdateFrom) > 0 {
+			sb.WriteString(" FROM " + tablesString(q.UpdateFrom))
+		}
+	case Delete:
+		sb.WriteString("DELETE FROM " + tableString(q.Schema, q.TableName))
+		if len(q.Using) > 0 {
+			sb.WriteString(" USING " + tablesString(q.Using))
+		}
+	case CreateTable:
+		columns := make([]string, len(q.Columns))
+		for i, c := range q.Columns {
+			columns[i] = c.Name + " " + c.Type
+			if len(c.TypeParams) > 0 {
+				params := make([]string, len(c.TypeParams))
+				for j, p := range c.TypeParams {
+					params[j] = strconv.Itoa(p)
+				}
+				columns[i] += "(" + strings.Join(params, ", ") + ")"
+			}
+			if c.NotNull {
+				columns[i] += " NOT NULL"
+			}
+			if c.PrimaryKey {
+				columns[i] += " PRIMARY KEY"
+			}
+			if c.DefaultType != UnknownOperandType {
+				columns[i] += " DEFAULT " + operandString(c.Default, c.DefaultType)
+			}
+		}
+		sb.WriteString("CREATE TABLE " + tableString(q.Schema, q.TableName) + " (" + strings.Join(columns, ", ") + ")")
+	case DropTable:
+		sb.WriteString("DROP TABLE ")
+		if q.IfExists {
+			sb.WriteString("IF EXISTS ")
+		}
+		sb.WriteString(tableString(q.Schema, q.TableName))
+	case Truncate:
+		sb.WriteString("TRUNCATE TABLE " + tableString(q.Schema, q.TableName))
+	case Describe:
+		sb.WriteString("DESCRIBE " + tableString(q.Schema, q.TableName))
+	case Show:
+		if q.ShowKind == ShowColumns {
+			sb.WriteString("SHOW COLUMNS FROM " + tableString(q.Schema, q.TableName))
+		} else {
+			sb.WriteString("SHOW TABLES")
+		}
+	case Call:
+		args := make([]string, len(q.Args))
+		for i, a := range q.Args {
+			args[i] = operandString(a, operandTypeAt(q.ArgTypes, i))
+		}
+		procName := q.ProcName
+		if q.Schema != "" {
+			procName = q.Schema + "." + procName
+		}
+		sb.WriteString("CALL " + procName + "(" + strings.Join(args, ", ") + ")")
+	}
+	if q.WhereExpr != nil {
+		sb.WriteString(" WHERE " + q.WhereExpr.String())
+	} else if len(q.Conditions) > 0 {
+		sb.WriteString(" WHERE " + conditionsString(q.Conditions))
+	}
+	if len(q.GroupBy) > 0 {
+		sb.WriteString(" GROUP BY " + strings.Join(q.GroupBy, ", "))
+	}
+	if len(q.Having) > 0 {
+		sb.WriteString(" HAVING " + conditionsString(q.Having))
+	}
+	if len(q.OrderBy) > 0 {
+		orderBy := make([]string, len(q.OrderBy))
+		for i, o := range q.OrderBy {
+			orderBy[i] = o.Field
+			if o.Ordinal > 0 {
+				orderBy[i] = strconv.Itoa(o.Ordinal)
+			}
+			if o.Direction == Desc {
+				orderBy[i] += " DESC"
+			}
+			switch o.Nulls {
+			case NullsFirst:
+				orderBy[i] += " NULLS FIRST"
+			case NullsLast:
+				orderBy[i] += " NULLS LAST"
+			}
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(orderBy, ", "))
+	}
+	if q.Limit != nil && !q.Top {
+		sb.WriteString(fmt.Sprintf(" LIMIT %d", *q.Limit))
+	}
+	if q.Offset != nil {
+		sb.WriteString(fmt.Sprintf(" OFFSET %d", *q.Offset))
+	}
+	if len(q.Returning) > 0 {
+		sb.WriteString(" RETURNING " + strings.Join(q.Returning, ", "))
+	}
+	if q.Union != nil {
+		sb.WriteString(" UNION ")
+		if q.Union.All {
+			sb.WriteString("ALL ")
+		}
+		sb.WriteString(q.Union.Query.String())
+	}
+	return sb.String()
+}
+
+// tablesString renders a list of table names, e.g. the ones after USING, which are qualified with their schema if any
+func tablesString(tables []string) string {
+	rendered := make([]string, len(tables))
+	for i, t := range tables {
+		rendered[i] = t
+		if !strings.Contains(t, ".") {
+			rendered[i] = quote(t)
+		}
+	}
+	return strings.Join(rendered, ", ")
+}
+
+func tableString(schema, tableName string) string {
+	if schema != "" {
+		return schema + "." + tableName
+	}
+	return quote(tableName)
+}
+
+var joinTypeKeywords = map[JoinType]string{
+	Inner: "INNER JOIN",
+	Left:  "LEFT JOIN",
+	Right: "RIGHT JOIN",
+	Full:  "FULL OUTER JOIN",
+}
+
+// String renders the condition back into SQL
+func (c Condition) String() string {
+	if c.Negated {
+		c.Negated = false
+		return "NOT " + c.String()
+	}
+	if c.Operator.IsUnary() {
+		return c.Operand1 + " " + c.Operator.String()
+	}
+	if c.Operator == Exists || c.Operator == NotExists {
+		return c.Operator.String() + " (" + c.Subquery.String() + ")"
+	}
+	operand2 := operandString(c.Operand2, c.Operand2Type)
+	switch {
+	case c.Operand2Type == OpSubquery:
+		operand2 = "(" + c.Subquery.String() + ")"
+	case c.Operator == In || c.Operator == NotIn:
+		values := make([]string, len(c.Operand2List))
+		for i, v := range c.Operand2List {
+			values[i] = operandString(v, operandTypeAt(c.Operand2ListTypes, i))
+		}
+		operand2 = "(" + strings.Join(values, ", ") + ")"
+	case c.Operator == Between:
+		operand2 += " AND " + operandString(c.Operand3, c.Operand3Type)
+	}
+	if c.Escape != "" {
+		operand2 += " ESCAPE " + quote(c.Escape)
+	}
+	if c.Collation != "" {
+		operand2 += " COLLATE " + c.Collation
+	}
+	return c.Operand1 + " " + c.Operator.String() + " " + operand2
+}
+
+// String renders the expression back into SQL
+func (e WhereExpr) String() string {
+	switch e.Type {
+	case Leaf:
+		return e.Condition.String()
+	case Group:
+		if e.Negated {
+			return "NOT (" + e.Children[0].String() + ")"
+		}
+		return "(" + e.Children[0].String() + ")"
+	}
+	children := make([]string, len(e.Children))
+	for i, c := range e.Children {
+		children[i] = c.String()
+	}
+	if e.Type == Or {
+		return strings.Join(children, " OR ")
+	}
+	return strings.Join(children, " AND ")
+}
+
+// updatesString renders assignments sorted by field, e.g. the SET ones of an UPDATE
+func updatesString(updates map[string]string, updateTypes map[string]OperandType) string {
+	fields := make([]string, 0, len(updates))
+	for f := range updates {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	assignments := make([]string, len(fields))
+	for i, f := range fields {
+		assignments[i] = f + " = " + operandString(updates[f], updateTypes[f])
+	}
+	return strings.Join(assignments, ", ")
+}
+
+func conditionsString(conditions []Condition) string {
+	var sb strings.Builder
+	for i, c := range conditions {
+		sb.WriteString(c.String())
+		if i == len(conditions)-1 {
+			break
+		}
+		if c.OrWithNext {
+			sb.WriteString(" OR ")
+		} else {
+			sb.WriteString(" AND ")
+		}
+	}
+	return sb.String()
+}
+
+// operandTypeAt returns the type of the i-th of some values, or OpQuoted if it's unknown, e.g. for a query that was
+// built by hand rather than parsed, so that the value is rendered in its quoted form
+func operandTypeAt(types []OperandType, i int) OperandType {
+	if i < len(types) {
+		return types[i]
+	}
+	return OpQuoted
+}
+
+func operandString(operand string, operandType OperandType) string {
+	switch operandType {
+	case OpQuoted:
+		return quote(operand)
+	case OpNull:
+		return "NULL"
+	}
+	return operand
+}
+
+// quote wraps a value in single quotes, escaping quotes by doubling them. Bytes escaped with a backslash are
+// kept as they were parsed, so e.g. \' isn't re-escaped but the quote in \\' is.
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('\'')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			sb.WriteString(s[i : i+2])
+			i++
+			continue
+		}
+		if s[i] == '\'' {
+			sb.WriteByte('\'')
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteByte('\'')
+	return sb.String()
+}
@@ -0,0 +1,201 @@
+package query
+
+import "sort"
+
+// FieldName is the node Walk visits for each field that isn't in a node of its own, i.e. each GROUP BY, INSERTed and
+// RETURNING one. Walk writes it back into the query after fn returns, so fn may rewrite it like any other node.
+type FieldName struct {
+	Name string
+}
+
+// Value is the node Walk visits for each value that isn't in a node of its own, i.e. each INSERTed value and each
+// argument of a CALL. Walk writes it back into the query after fn returns, so fn may rewrite it like any other node.
+type Value struct {
+	Value string
+	Type  OperandType
+}
+
+// Walk calls fn on every node of the query, depth-first, stopping as soon as fn returns false. Nodes are pointers into
+// the query, so fn may also rewrite them, e.g. to rename a table. They're visited in this order:
+//
+//   - the *Query itself, which holds the table name
+//   - each *FieldExpr, i.e. SELECTed field, followed by the arguments of function calls
+//   - each *Join, followed by the *Condition of its ON clause
+//   - each *Condition of the WHERE clause, followed by its Subquery, if any, walked likewise
+//   - each GROUP BY *FieldName
+//   - each *Condition of the HAVING clause
+//   - each *OrderByField
+//   - each *UpdatePair of UPDATE SET, in order, or sorted by field if the query has Updates but no UpdatePairs
+//   - each INSERTed *FieldName, followed by each *Value of INSERT, row by row
+//   - each *UpdatePair of ON DUPLICATE KEY UPDATE, sorted by field
+//   - each *Value that's an argument of CALL
+//   - each RETURNING *FieldName
+//   - each *ColumnDef of a CREATE TABLE
+//   - the nested queries, i.e. the derived table of FROM, the SELECT of an INSERT INTO ... SELECT and then the one
+//     after UNION, walked likewise
+func (q *Query) Walk(fn func(node interface{}) bool) {
+	q.walk(fn)
+}
+
+func (q *Query) walk(fn func(node interface{}) bool) bool {
+	if !fn(q) {
+		return false
+	}
+	for i := range q.FieldExprs {
+		if !q.FieldExprs[i].walk(fn) {
+			return false
+		}
+	}
+	for i := range q.Joins {
+		if !fn(&q.Joins[i]) || !walkConditions(q.Joins[i].On, fn) {
+			return false
+		}
+	}
+	if !walkConditions(q.Conditions, fn) || !walkFieldNames(q.GroupBy, fn) || !walkConditions(q.Having, fn) {
+		return false
+	}
+	for i := range q.OrderBy {
+		if !fn(&q.OrderBy[i]) {
+			return false
+		}
+	}
+	if len(q.UpdatePairs) > 0 {
+		if !q.walkUpdatePairs(fn) {
+			return false
+		}
+	} else if !walkAssignments(q.Updates, q.UpdateTypes, fn) {
+		return false
+	}
+	if (q.Type == Insert || q.Type == Replace) && !walkFieldNames(q.Fields, fn) {
+		return false
+	}
+	for i := range q.Inserts {
+		var rowTypes []OperandType
+		if i < len(q.InsertTypes) {
+			rowTypes = q.InsertTypes[i]
+		}
+		if !walkValues(q.Inserts[i], rowTypes, fn) {
+			return false
+		}
+	}
+	if !walkAssignments(q.OnDuplicate, q.OnDuplicateTypes, fn) || !walkValues(q.Args, q.ArgTypes, fn) {
+		return false
+	}
+	if !walkFieldNames(q.Returning, fn) {
+		return false
+	}
+	for i := range q.Columns {
+		if !fn(&q.Columns[i]) {
+			return false
+		}
+	}
+	if q.FromSubquery != nil && !q.FromSubquery.walk(fn) {
+		return false
+	}
+	if q.InsertSelect != nil && !q.InsertSelect.walk(fn) {
+		return false
+	}
+	if q.Union != nil && !q.Union.Query.walk(fn) {
+		return false
+	}
+	return true
+}
+
+func (e *FieldExpr) walk(fn func(node interface{}) bool) bool {
+	if !fn(e) {
+		return false
+	}
+	for i := range e.Args {
+		if !e.Args[i].walk(fn) {
+			return false
+		}
+	}
+	return true
+}
+
+func walkFieldNames(names []string, fn func(node interface{}) bool) bool {
+	for i := range names {
+		name := FieldName{Name: names[i]}
+		ok := fn(&name)
+		names[i] = name.Name
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// walkValues walks values along with their types, which may be missing, e.g. for a query that was built by hand, in
+// which case rewritten types aren't written back
+func walkValues(values []string, types []OperandType, fn func(node interface{}) bool) bool {
+	for i := range values {
+		value := Value{Value: values[i], Type: operandTypeAt(types, i)}
+		ok := fn(&value)
+		values[i] = value.Value
+		if i < len(types) {
+			types[i] = value.Type
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// walkUpdatePairs walks the assignments of UPDATE SET in order, keeping Updates and UpdateTypes in sync with them
+func (q *Query) walkUpdatePairs(fn func(node interface{}) bool) bool {
+	visited := append([]UpdatePair(nil), q.UpdatePairs...)
+	ok := true
+	for i := 0; i < len(q.UpdatePairs) && ok; i++ {
+		ok = fn(&q.UpdatePairs[i])
+	}
+	for i := range visited {
+		if visited[i] == q.UpdatePairs[i] {
+			continue
+		}
+		q.Updates, q.UpdateTypes = map[string]string{}, map[string]OperandType{}
+		for _, u := range q.UpdatePairs {
+			q.Updates[u.Field], q.UpdateTypes[u.Field] = u.Value, u.Type
+		}
+		break
+	}
+	return ok
+}
+
+// walkAssignments walks assignments sorted by field, e.g. the ones of ON DUPLICATE KEY UPDATE. Types may be nil, e.g.
+// for a query that was built by hand, in which case rewritten types aren't written back.
+func walkAssignments(values map[string]string, types map[string]OperandType, fn func(node interface{}) bool) bool {
+	fields := make([]string, 0, len(values))
+	for f := range values {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+	for _, f := range fields {
+		pair := UpdatePair{Field: f, Value: values[f], Type: types[f]}
+		ok := fn(&pair)
+		if pair.Field != f {
+			delete(values, f)
+			delete(types, f)
+		}
+		values[pair.Field] = pair.Value
+		if types != nil {
+			types[pair.Field] = pair.Type
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func walkConditions(conditions []Condition, fn func(node interface{}) bool) bool {
+	for i := range conditions {
+		if !fn(&conditions[i]) {
+			return false
+		}
+		if conditions[i].Subquery != nil && !conditions[i].Subquery.walk(fn) {
+			return false
+		}
+	}
+	return true
+}
@@ -1,20 +1,99 @@
 package sqlparser
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"regexp"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/marianogappa/sqlparser/query"
 )
 
-// Parse takes a string representing a SQL query and parses it into a query.Query struct. It may fail.
+// Parse takes a string representing a SQL query and parses it into a query.Query struct. It may fail, but it never
+// panics: any malformed SQL is reported as an error, which FuzzParse checks.
 func Parse(sqls string) (query.Query, error) {
-	qs, err := ParseMany([]string{sqls})
-	if len(qs) == 0 {
+	return ParseContext(context.Background(), sqls)
+}
+
+// ParseContext is like Parse, but it aborts with the context's error if the context is done before parsing finishes
+func ParseContext(ctx context.Context, sql string) (query.Query, error) {
+	q, err := parse(ctx, sql, Options{})
+	if err != nil {
+		return query.Query{}, err
+	}
+	return q, nil
+}
+
+// Options are strictness and dialect toggles for ParseWithOptions. The zero value parses like Parse.
+type Options struct {
+	// RejectDuplicateFields makes a SELECT fail if it has the same field twice, e.g. SELECT a, a FROM 'b'
+	RejectDuplicateFields bool
+	// IdentifierQuotes are the characters that may quote identifiers, e.g. `"` for Postgres or "`" for MySQL, so
+	// that they may be reserved words or have any characters. Quoted table names are unquoted, like 'single quoted'
+	// ones, but quoted fields are kept as written. Single quotes always quote strings (and table names).
+	IdentifierQuotes string
+	// RejectUnquotedTableNames makes a query fail if its table names are not quoted
+	RejectUnquotedTableNames bool
+	// Semicolon is whether the query must, may or must not end with a semicolon
+	Semicolon SemicolonPolicy
+	// Functions, if set, are the only functions that SELECTed fields may call, matched case-insensitively, e.g. to
+	// reject a typo like cuont(*). Functions called within arguments are checked too.
+	Functions []string
+}
+
+// SemicolonPolicy is whether a query must, may or must not end with a semicolon
+type SemicolonPolicy int
+
+const (
+	// SemicolonOptional allows a query to end with a semicolon or not, which is the default
+	SemicolonOptional SemicolonPolicy = iota
+	// SemicolonRequired makes a query fail if it doesn't end with a semicolon
+	SemicolonRequired
+	// SemicolonForbidden makes a query fail if it ends with a semicolon
+	SemicolonForbidden
+)
+
+// ParseWithOptions is like Parse, but with the given options
+func ParseWithOptions(sql string, options Options) (query.Query, error) {
+	q, err := parse(context.Background(), sql, options)
+	if err != nil {
 		return query.Query{}, err
 	}
-	return qs[0], err
+	return q, nil
+}
+
+// ParseSelect is like Parse, but it fails if the query is not a SELECT
+func ParseSelect(sql string) (query.Query, error) {
+	return parseOfType(sql, query.Select)
+}
+
+// ParseInsert is like Parse, but it fails if the query is not an INSERT
+func ParseInsert(sql string) (query.Query, error) {
+	return parseOfType(sql, query.Insert)
+}
+
+// ParseUpdate is like Parse, but it fails if the query is not an UPDATE
+func ParseUpdate(sql string) (query.Query, error) {
+	return parseOfType(sql, query.Update)
+}
+
+// ParseDelete is like Parse, but it fails if the query is not a DELETE
+func ParseDelete(sql string) (query.Query, error) {
+	return parseOfType(sql, query.Delete)
+}
+
+func parseOfType(sql string, expected query.Type) (query.Query, error) {
+	q, err := Parse(sql)
+	if err != nil {
+		return q, err
+	}
+	if q.Type != expected {
+		return query.Query{}, fmt.Errorf("expected query type %s, but got %s", query.TypeString[expected], query.TypeString[q.Type])
+	}
+	return q, nil
 }
 
 // ParseMany takes a string slice representing many SQL queries and parses them into a query.Query struct slice.
@@ -22,7 +101,7 @@ func Parse(sqls string) (query.Query, error) {
 func ParseMany(sqls []string) ([]query.Query, error) {
 	qs := []query.Query{}
 	for _, sql := range sqls {
-		q, err := parse(sql)
+		q, err := parse(context.Background(), sql, Options{})
 		if err != nil {
 			return qs, err
 		}
@@ -31,8 +110,182 @@ func ParseMany(sqls []string) ([]query.Query, error) {
 	return qs, nil
 }
 
-func parse(sql string) (query.Query, error) {
-	return (&parser{0, strings.TrimSpace(sql), stepType, query.Query{}, nil, ""}).parse()
+// ParseAll takes a string slice representing many SQL queries and parses all of them, even if some fail. It returns
+// a query.Query struct slice and an error slice, both parallel to sqls: each query is the zero value where its
+// error isn't nil.
+func ParseAll(sqls []string) ([]query.Query, []error) {
+	qs := make([]query.Query, len(sqls))
+	errs := make([]error, len(sqls))
+	for i, sql := range sqls {
+		q, err := parse(context.Background(), sql, Options{})
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		qs[i] = q
+	}
+	return qs, errs
+}
+
+// ParseScript takes a string with many SQL queries separated by semicolons and parses them into a query.Query struct
+// slice. Empty statements are skipped. It may fail. If it fails, it will stop at the first failure.
+func ParseScript(sql string) ([]query.Query, error) {
+	return ParseMany(splitStatements(sql))
+}
+
+// ParseReader is like ParseScript, but it reads the queries from r as it parses them, so that a big script doesn't
+// need to be read whole before parsing it. It may fail. If it fails, it will stop at the first failure.
+func ParseReader(r io.Reader) ([]query.Query, error) {
+	qs := []query.Query{}
+	err := ParseReaderFunc(r, func(q query.Query) error {
+		qs = append(qs, q)
+		return nil
+	})
+	return qs, err
+}
+
+// ParseReaderFunc reads queries separated by semicolons from r and calls fn with each one as soon as it's parsed,
+// so that they're not kept in memory. Empty statements are skipped. It stops at the first failure, either reading,
+// parsing or of fn, and returns it.
+func ParseReaderFunc(r io.Reader, fn func(query.Query) error) error {
+	s := &statementScanner{}
+	// Whatever a read returns is parsed right away, rather than waiting for a full chunk, so that fn is called as
+	// soon as a statement arrives even if r is slow, e.g. a network connection
+	chunk := make([]byte, chunkSize)
+	for atEOF := false; !atEOF; {
+		n, err := r.Read(chunk)
+		atEOF = err == io.EOF
+		if err != nil && !atEOF {
+			return err
+		}
+		s.sql += string(chunk[:n])
+		for end := s.next(atEOF); end != -1; end = s.next(atEOF) {
+			isLast := end == len(s.sql)
+			if statement := s.pop(end); statement != "" {
+				q, err := parse(context.Background(), statement, Options{})
+				if err != nil {
+					return err
+				}
+				if err := fn(q); err != nil {
+					return err
+				}
+			}
+			if isLast {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+const chunkSize = 32 * 1024
+
+// splitStatements splits sql on the semicolons that are neither within quoted strings nor within comments, skipping
+// statements that are empty or only have comments
+func splitStatements(sql string) []string {
+	statements := []string{}
+	s := &statementScanner{sql: sql}
+	for {
+		end := s.next(true)
+		isLast := end == len(s.sql)
+		if statement := s.pop(end); statement != "" {
+			statements = append(statements, statement)
+		}
+		if isLast {
+			return statements
+		}
+	}
+}
+
+// statementScanner finds where statements end, i.e. the semicolons that are neither within quoted strings nor within
+// comments, in SQL that may be read in chunks
+type statementScanner struct {
+	sql        string
+	i          int  // How far sql has been scanned
+	inQuotes   bool // Whether sql[i] is within a quoted string
+	hasContent bool // Whether the statement being scanned has anything besides whitespace and comments
+}
+
+// next returns the index of the semicolon that ends the statement being scanned, or -1 if there's none in sql yet.
+// Unless atEOF, it stops before anything that may go on in the SQL that's yet to be read, e.g. a comment. With atEOF,
+// it returns len(sql) if there's no semicolon, since the end of the SQL also ends the statement.
+func (s *statementScanner) next(atEOF bool) int {
+	for ; s.i < len(s.sql); s.i++ {
+		isLastByte := s.i == len(s.sql)-1 && !atEOF
+		if s.inQuotes {
+			if s.sql[s.i] == '\\' && isLastByte {
+				return -1 // It escapes the byte that's yet to be read
+			}
+			if s.sql[s.i] == '\\' {
+				s.i++
+			} else if s.sql[s.i] == '\'' {
+				s.inQuotes = false
+			}
+			continue
+		}
+		if (s.sql[s.i] == '-' || s.sql[s.i] == '/') && isLastByte {
+			return -1 // It may start a comment
+		}
+		if ln := commentLength(s.sql, s.i); ln > 0 {
+			comment := s.sql[s.i : s.i+ln]
+			if s.i+ln == len(s.sql) && !atEOF && !strings.HasSuffix(comment, "\n") && !(ln >= 4 && strings.HasSuffix(comment, "*/")) {
+				return -1 // The comment may go on
+			}
+			if isUnterminatedComment(s.sql, s.i) {
+				s.hasContent = true // So that parsing the statement reports it
+			}
+			s.i += ln - 1
+			continue
+		}
+		if s.sql[s.i] == ';' {
+			return s.i
+		}
+		if s.sql[s.i] == '\'' && isAfterEscape(s.sql, s.i) {
+			if s.i+3 >= len(s.sql) && !atEOF {
+				return -1 // It may be a quoted backslash, which depends on the byte that's yet to be read
+			}
+			if ln := quotedBackslashLength(s.sql, s.i); ln > 0 {
+				s.i += ln - 1
+				s.hasContent = true
+				continue
+			}
+		}
+		if s.sql[s.i] == '\'' {
+			s.inQuotes = true
+		}
+		if !isWhitespace(s.sql[s.i]) {
+			s.hasContent = true
+		}
+	}
+	if atEOF {
+		return len(s.sql)
+	}
+	return -1
+}
+
+// pop removes the statement that ends at end, as returned by next, and returns it trimmed, or "" if it has nothing
+// besides whitespace and comments
+func (s *statementScanner) pop(end int) string {
+	statement := ""
+	if s.hasContent {
+		statement = strings.TrimSpace(s.sql[:end])
+	}
+	if end < len(s.sql) {
+		end++ // The semicolon
+	}
+	s.sql, s.i, s.hasContent = s.sql[end:], 0, false
+	return statement
+}
+
+func parse(ctx context.Context, sql string, options Options) (query.Query, error) {
+	trimmedSQL := strings.TrimSpace(sql)
+	// Positions are on the untrimmed SQL, so that their line and column are the ones the caller sees
+	p := &parser{ctx: ctx, sql: trimmedSQL, offset: strings.Index(sql, trimmedSQL), step: stepType, options: options}
+	q, err := p.parse()
+	if err != nil {
+		return q, &ErrorWithPos{msg: err.Error(), err: err, sql: sql, pos: p.offset + p.i}
+	}
+	return q, nil
 }
 
 type step int
@@ -59,82 +312,310 @@ const (
 	stepUpdateValue
 	stepUpdateComma
 	stepDeleteFromTable
+	stepCreateTableName
+	stepCreateTableOpeningParens
+	stepCreateTableColumn
+	stepCreateTableColumnType
+	stepCreateTableColumnModifierCommaOrClosingParens
+	stepCreateTableAfterClosingParens
+	stepDropTableName
+	stepDropTableAfterName
+	stepTruncateTable
+	stepTruncateAfterTable
+	stepCallProcName
+	stepCallOpeningParens
+	stepCallArg
+	stepCallCommaOrClosingParens
+	stepCallAfterClosingParens
+	stepDescribeTable
+	stepDescribeAfterTable
+	stepShowKind
+	stepShowColumnsFrom
+	stepShowColumnsTable
+	stepShowEnd
+	stepJoin
+	stepJoinTable
+	stepJoinOn
 	stepWhere
-	stepWhereField
-	stepWhereOperator
-	stepWhereValue
-	stepWhereAnd
+	stepConditionField
+	stepConditionOperator
+	stepConditionValue
+	stepConditionInOpeningParens
+	stepConditionInValues
+	stepConditionInValuesCommaOrClosingParens
+	stepConditionBetweenLowerBound
+	stepConditionBetweenAnd
+	stepConditionBetweenUpperBound
+	stepConditionLikePattern
+	stepConditionConnector
+	stepGroupBy
+	stepGroupByField
+	stepGroupByComma
+	stepHaving
+	stepOrderBy
+	stepOrderByField
+	stepOrderByComma
+	stepLimit
+	stepLimitValue
+	stepLimitComma
+	stepOffset
+	stepOffsetValue
+	stepAfterOffset
+	stepUnion
+	stepReturning
+	stepReturningField
+	stepReturningComma
+	stepUsing
+	stepUpdateFrom
+	stepTablesTable
+	stepTablesComma
 )
 
+// clause is an optional clause that may follow the table name, e.g. WHERE or ORDER BY
+type clause struct {
+	rWord string
+	step  step
+}
+
+// clauses are the optional clauses of each query type, in the order in which they must appear. Clauses parsed by
+// stepJoin may appear many times in a row.
+var clauses = map[query.Type][]clause{
+	query.Select: {
+		{"JOIN", stepJoin}, {"INNER JOIN", stepJoin}, {"LEFT JOIN", stepJoin}, {"LEFT OUTER JOIN", stepJoin},
+		{"RIGHT JOIN", stepJoin}, {"RIGHT OUTER JOIN", stepJoin}, {"FULL JOIN", stepJoin}, {"FULL OUTER JOIN", stepJoin},
+		{"WHERE", stepWhere}, {"GROUP BY", stepGroupBy}, {"HAVING", stepHaving}, {"ORDER BY", stepOrderBy}, {"LIMIT", stepLimit}, {"OFFSET", stepOffset},
+		{"UNION", stepUnion}, {"UNION ALL", stepUnion},
+	},
+	query.Insert:  {{"RETURNING", stepReturning}},
+	query.Replace: {{"RETURNING", stepReturning}},
+	query.Update:  {{"FROM", stepUpdateFrom}, {"WHERE", stepWhere}, {"RETURNING", stepReturning}},
+	query.Delete:  {{"USING", stepUsing}, {"WHERE", stepWhere}, {"LIMIT", stepLimit}, {"RETURNING", stepReturning}},
+}
+
+// joinTypes maps each reserved word that starts a JOIN to its type; OUTER is optional, so it's normalized away
+var joinTypes = map[string]query.JoinType{
+	"JOIN":             query.Inner,
+	"INNER JOIN":       query.Inner,
+	"LEFT JOIN":        query.Left,
+	"LEFT OUTER JOIN":  query.Left,
+	"RIGHT JOIN":       query.Right,
+	"RIGHT OUTER JOIN": query.Right,
+	"FULL JOIN":        query.Full,
+	"FULL OUTER JOIN":  query.Full,
+}
+
 type parser struct {
-	i               int
-	sql             string
-	step            step
-	query           query.Query
-	err             error
-	nextUpdateField string
+	ctx              context.Context
+	options          Options
+	semicolonPos     int // Where the semicolon that terminates the query is, or 0 if there's none
+	i                int
+	sql              string
+	offset           int // Where sql is in the SQL being parsed, e.g. when it's trimmed or nested, for the positions in the query
+	step             step
+	query            query.Query
+	err              error
+	nextUpdateField  string
+	updates          map[string]string            // The assignments being parsed, i.e. UPDATE's or ON DUPLICATE KEY UPDATE's
+	updateTypes      map[string]query.OperandType // The kind of value of each assignment being parsed
+	updatesRWord     string                       // The reserved word that started the assignments being parsed, e.g. "UPDATE"
+	tables           *[]string                    // The table names being parsed, e.g. DELETE's USING ones
+	tablesRWord      string                       // The reserved word that started the table names being parsed, e.g. "USING"
+	tablesClause     step                         // The clause the table names being parsed belong to, e.g. stepUsing
+	conditions       *[]query.Condition           // The conditions being parsed, e.g. the WHERE, HAVING or a JOIN's ON ones
+	conditionsRWord  string                       // The reserved word that started the conditions being parsed, e.g. "WHERE"
+	conditionsClause step                         // The clause the conditions being parsed belong to, e.g. stepWhere
+	conditionsExpr   **query.WhereExpr            // Where the expression tree of the conditions being parsed goes, if they may be grouped with parens
+	conditionsTokens []int                        // The conditions being parsed, as indexes into conditions, and the parens that group them
+	conditionsDepth  int                          // How many parens are open in the conditions being parsed
+	conditionNegated bool                         // Whether the next condition or group of conditions is preceded by NOT
+	peeked           Token                        // The last token peeked, so that peeking it again, e.g. to pop it, doesn't scan it again
+	peekedLn         int                          // The length of peeked, or 0 if there's none
+	outer            *parser                      // The parser of the enclosing query, if this one parses a subquery
 }
 
+// Besides the indexes of conditions, conditionsTokens has these tokens for the parens that group them
+const (
+	openingParensToken        = -1
+	closingParensToken        = -2
+	negatedOpeningParensToken = -3 // i.e. NOT (
+)
+
 func (p *parser) parse() (query.Query, error) {
 	q, err := p.doParse()
 	p.err = err
 	if p.err == nil {
 		p.err = p.validate()
 	}
-	p.logError()
+	if p.err == nil {
+		p.err = p.validateSemicolon()
+	}
 	return q, p.err
 }
 
+// validateSemicolon checks the semicolon at the end of the query, if any, against the Semicolon option
+func (p *parser) validateSemicolon() error {
+	if p.options.Semicolon == SemicolonRequired && p.semicolonPos == 0 {
+		p.i = len(p.sql)
+		return fmt.Errorf("expected semicolon at the end of the query")
+	}
+	if p.options.Semicolon == SemicolonForbidden && p.semicolonPos != 0 {
+		p.i = p.semicolonPos
+		return fmt.Errorf("unexpected semicolon at the end of the query")
+	}
+	return nil
+}
+
 func (p *parser) doParse() (query.Query, error) {
+	lastI, lastStep := -1, p.step
+	p.popWhitespace() // There may be comments before the query
 	for {
+		if err := p.ctx.Err(); err != nil {
+			return p.query, err
+		}
 		if p.i >= len(p.sql) {
+			if p.step == stepConditionConnector {
+				p.err = p.endConditions()
+			}
 			return p.query, p.err
 		}
+		if isUnterminatedComment(p.sql, p.i) {
+			return p.query, fmt.Errorf("unterminated comment")
+		}
+		// A semicolon terminates the statement, so it's parsed as if the SQL had ended right before it
+		if p.sql[p.i] == ';' {
+			p.semicolonPos = p.i
+			p.popLength(1)
+			if p.i < len(p.sql) {
+				return p.query, fmt.Errorf("unexpected token after semicolon")
+			}
+			continue
+		}
+		// Every iteration must either consume some SQL or move on to another step, or the parser would spin forever
+		if p.i == lastI && p.step == lastStep {
+			return p.query, fmt.Errorf("unexpected token")
+		}
+		lastI, lastStep = p.i, p.step
 		switch p.step {
 		case stepType:
-			switch strings.ToUpper(p.peek()) {
+			switch p.peekReservedWord() {
 			case "SELECT":
 				p.query.Type = query.Select
 				p.pop()
+				if p.peek() == "DISTINCT" {
+					p.query.Distinct = true
+					p.pop()
+				}
+				if p.peek() == "TOP" {
+					p.pop()
+					top, ok := p.peekNonNegativeInteger()
+					if !ok {
+						return p.query, fmt.Errorf("at SELECT: expected non-negative integer after TOP")
+					}
+					p.query.Limit, p.query.Top = &top, true
+					p.pop()
+				}
 				p.step = stepSelectField
-			case "INSERT INTO":
+			case "INSERT INTO", "REPLACE INTO":
 				p.query.Type = query.Insert
+				if p.peek() == "REPLACE INTO" {
+					p.query.Type = query.Replace
+				}
 				p.pop()
 				p.step = stepInsertTable
 			case "UPDATE":
 				p.query.Type = query.Update
 				p.query.Updates = map[string]string{}
+				p.query.UpdateTypes = map[string]query.OperandType{}
+				p.updates, p.updateTypes, p.updatesRWord = p.query.Updates, p.query.UpdateTypes, "UPDATE"
 				p.pop()
 				p.step = stepUpdateTable
 			case "DELETE FROM":
 				p.query.Type = query.Delete
 				p.pop()
 				p.step = stepDeleteFromTable
+			case "CREATE TABLE":
+				p.query.Type = query.CreateTable
+				p.pop()
+				p.step = stepCreateTableName
+			case "DROP TABLE":
+				p.query.Type = query.DropTable
+				p.pop()
+				if p.peek() == "IF EXISTS" {
+					p.query.IfExists = true
+					p.pop()
+				}
+				p.step = stepDropTableName
+			case "TRUNCATE TABLE", "TRUNCATE":
+				p.query.Type = query.Truncate
+				p.pop()
+				p.step = stepTruncateTable
+			case "CALL":
+				p.query.Type = query.Call
+				p.pop()
+				p.step = stepCallProcName
+			case "DESCRIBE", "DESC": // Only here it's not the ORDER BY direction
+				p.query.Type = query.Describe
+				p.pop()
+				p.step = stepDescribeTable
+			case "SHOW":
+				p.query.Type = query.Show
+				p.pop()
+				p.step = stepShowKind
+			case "EXPLAIN", "EXPLAIN ANALYZE":
+				if p.query.Explain {
+					return p.query, fmt.Errorf("at EXPLAIN: expected query to explain")
+				}
+				p.query.Explain = true
+				p.query.Analyze = p.peek() == "EXPLAIN ANALYZE"
+				p.pop() // The query to explain comes next, so the step stays the same
 			default:
 				return p.query, fmt.Errorf("invalid query type")
 			}
 		case stepSelectField:
 			identifier := p.peek()
-			if !isIdentifierOrAsterisk(identifier) {
+			if identifier == "DISTINCT" {
+				return p.query, fmt.Errorf("at SELECT: DISTINCT must come right after SELECT")
+			}
+			if !p.isFieldAhead() {
 				return p.query, fmt.Errorf("at SELECT: expected field to SELECT")
 			}
+			identifier, text, err := p.popField("SELECT")
+			if err != nil {
+				return p.query, err
+			}
+			if p.options.RejectDuplicateFields {
+				for _, field := range p.query.Fields {
+					if field == identifier {
+						return p.query, fmt.Errorf("at SELECT: duplicate field %s", identifier)
+					}
+				}
+			}
+			expr := parseFieldExpr(text)
+			if err := p.validateFunctions(expr); err != nil {
+				return p.query, err
+			}
 			p.query.Fields = append(p.query.Fields, identifier)
-			p.pop()
-			maybeFrom := p.peek()
-			if strings.ToUpper(maybeFrom) == "AS" {
+			p.query.FieldExprs = append(p.query.FieldExprs, expr)
+			hasAS := p.peekReservedWord() == "AS"
+			if hasAS {
 				p.pop()
-				alias := p.peek()
-				if !isIdentifier(alias) {
+				if !isIdentifier(p.peek()) {
 					return p.query, fmt.Errorf("at SELECT: expected field alias for \"" + identifier + " as\" to SELECT")
 				}
+			}
+			if alias := p.peek(); hasAS || isIdentifier(alias) { // The alias may also come on its own, e.g. SELECT a b
+				for _, existing := range p.query.Aliases {
+					if existing == alias {
+						return p.query, fmt.Errorf("at SELECT: duplicate alias %s", alias)
+					}
+				}
 				if p.query.Aliases == nil {
 					p.query.Aliases = make(map[string]string)
 				}
 				p.query.Aliases[identifier] = alias
 				p.pop()
-				maybeFrom = p.peek()
 			}
-			if strings.ToUpper(maybeFrom) == "FROM" {
+			if p.peekReservedWord() == "FROM" {
 				p.step = stepSelectFrom
 				continue
 			}
@@ -147,43 +628,91 @@ func (p *parser) doParse() (query.Query, error) {
 			p.pop()
 			p.step = stepSelectField
 		case stepSelectFrom:
-			fromRWord := p.peek()
-			if strings.ToUpper(fromRWord) != "FROM" {
+			if p.peekReservedWord() != "FROM" {
 				return p.query, fmt.Errorf("at SELECT: expected FROM")
 			}
 			p.pop()
 			p.step = stepSelectFromTable
 		case stepSelectFromTable:
-			tableName := p.peek()
-			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at SELECT: expected quoted table name")
+			if p.peek() == "(" { // Derived table, e.g. FROM (SELECT a FROM b) AS c
+				subquery, err := p.parseSubquery("SELECT")
+				if err != nil {
+					return p.query, err
+				}
+				p.query.FromSubquery = subquery
+			} else {
+				schema, tableName, err := p.popTableName("SELECT")
+				if err != nil {
+					return p.query, err
+				}
+				p.query.Schema = schema
+				p.query.TableName = tableName
+			}
+			tableAlias, err := p.popTableAlias("SELECT")
+			if err != nil {
+				return p.query, err
+			}
+			if p.query.FromSubquery != nil && tableAlias == "" {
+				return p.query, fmt.Errorf("at SELECT: expected alias for derived table")
+			}
+			p.query.TableAlias = tableAlias
+			if next, ok := p.nextClause(stepSelectFromTable); ok {
+				p.step = next
+				continue
 			}
-			p.query.TableName = tableName
-			p.pop()
 			p.step = stepWhere
 		case stepInsertTable:
-			tableName := p.peek()
-			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at INSERT INTO: expected quoted table name")
+			schema, tableName, err := p.popTableName(p.insertRWord())
+			if err != nil {
+				return p.query, err
 			}
+			p.query.Schema = schema
 			p.query.TableName = tableName
-			p.pop()
 			p.step = stepInsertFieldsOpeningParens
 		case stepDeleteFromTable:
-			tableName := p.peek()
-			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at DELETE FROM: expected quoted table name")
+			schema, tableName, err := p.popTableName("DELETE FROM")
+			if err != nil {
+				return p.query, err
 			}
+			p.query.Schema = schema
 			p.query.TableName = tableName
-			p.pop()
 			p.step = stepWhere
+			if p.peekReservedWord() == "USING" {
+				p.step = stepUsing
+			}
+		case stepUsing:
+			p.pop()
+			p.startTables(&p.query.Using, "USING", stepUsing)
+		case stepUpdateFrom:
+			p.pop()
+			p.startTables(&p.query.UpdateFrom, "FROM", stepUpdateFrom)
+		case stepTablesTable:
+			schema, tableName, err := p.popTableName(p.tablesRWord)
+			if err != nil {
+				return p.query, err
+			}
+			if schema != "" {
+				tableName = schema + "." + tableName
+			}
+			*p.tables = append(*p.tables, tableName)
+			p.step = stepTablesComma
+		case stepTablesComma:
+			if next, ok := p.nextClause(p.tablesClause); ok {
+				p.step = next
+				continue
+			}
+			if p.peek() != "," {
+				return p.query, fmt.Errorf("at %s: expected comma", p.tablesRWord)
+			}
+			p.pop()
+			p.step = stepTablesTable
 		case stepUpdateTable:
-			tableName := p.peek()
-			if len(tableName) == 0 {
-				return p.query, fmt.Errorf("at UPDATE: expected quoted table name")
+			schema, tableName, err := p.popTableName("UPDATE")
+			if err != nil {
+				return p.query, err
 			}
+			p.query.Schema = schema
 			p.query.TableName = tableName
-			p.pop()
 			p.step = stepUpdateSet
 		case stepUpdateSet:
 			setRWord := p.peek()
@@ -194,8 +723,13 @@ func (p *parser) doParse() (query.Query, error) {
 			p.step = stepUpdateField
 		case stepUpdateField:
 			identifier := p.peek()
+			// An UPDATE without assignments is parsed on, so that validate reports it rather than a confusing token
+			if next, ok := p.nextClause(stepUpdateField); ok && p.query.Type == query.Update && len(p.updates) == 0 {
+				p.step = next
+				continue
+			}
 			if !isIdentifier(identifier) {
-				return p.query, fmt.Errorf("at UPDATE: expected at least one field to update")
+				return p.query, fmt.Errorf("at %s: expected at least one field to update", p.updatesRWord)
 			}
 			p.nextUpdateField = identifier
 			p.pop()
@@ -203,289 +737,1984 @@ func (p *parser) doParse() (query.Query, error) {
 		case stepUpdateEquals:
 			equalsRWord := p.peek()
 			if equalsRWord != "=" {
-				return p.query, fmt.Errorf("at UPDATE: expected '='")
+				return p.query, fmt.Errorf("at %s: expected '='", p.updatesRWord)
 			}
 			p.pop()
 			p.step = stepUpdateValue
 		case stepUpdateValue:
-			quotedValue, ln := p.peekQuotedStringWithLength()
+			value, valueType, ln := p.peekValueOrNullWithLength()
+			if placeholder, placeholderLn := p.peekPlaceholderWithLength(); placeholderLn > 0 {
+				value, valueType, ln = placeholder, query.OpPlaceholder, placeholderLn
+				p.addParam(placeholder)
+			}
 			if ln == 0 {
-				return p.query, fmt.Errorf("at UPDATE: expected quoted value")
+				identifier := withoutInnerOperator(p.peek(), "-*")
+				if !isIdentifier(identifier) {
+					return p.query, fmt.Errorf("at %s: expected quoted value", p.updatesRWord)
+				}
+				value, valueType, ln = identifier, query.OpField, len(identifier)
+			}
+			start := p.i
+			p.popLength(ln)
+			expression, err := p.popArithmetic(start, start+ln, p.updatesRWord, "-*")
+			if err != nil {
+				return p.query, err
 			}
-			p.query.Updates[p.nextUpdateField] = quotedValue
+			if expression != "" {
+				value, valueType = expression, query.OpExpression
+			}
+			p.updates[p.nextUpdateField] = value
+			if p.query.Type == query.Update {
+				p.query.UpdatePairs = append(p.query.UpdatePairs, query.UpdatePair{Field: p.nextUpdateField, Value: value, Type: valueType})
+			}
+			p.updateTypes[p.nextUpdateField] = valueType
 			p.nextUpdateField = ""
-			p.pop()
-			maybeWhere := p.peek()
-			if strings.ToUpper(maybeWhere) == "WHERE" {
-				p.step = stepWhere
+			if next, ok := p.nextClause(stepUpdateValue); ok {
+				p.step = next
 				continue
 			}
 			p.step = stepUpdateComma
 		case stepUpdateComma:
 			commaRWord := p.peek()
 			if commaRWord != "," {
-				return p.query, fmt.Errorf("at UPDATE: expected ','")
+				return p.query, fmt.Errorf("at %s: expected ','", p.updatesRWord)
 			}
 			p.pop()
 			p.step = stepUpdateField
-		case stepWhere:
-			whereRWord := p.peek()
-			if strings.ToUpper(whereRWord) != "WHERE" {
-				return p.query, fmt.Errorf("expected WHERE")
+		case stepCreateTableName:
+			schema, tableName, err := p.popTableName("CREATE TABLE")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Schema = schema
+			p.query.TableName = tableName
+			p.step = stepCreateTableOpeningParens
+		case stepCreateTableOpeningParens:
+			openingParens := p.peek()
+			if openingParens != "(" {
+				return p.query, fmt.Errorf("at CREATE TABLE: expected opening parens")
 			}
 			p.pop()
-			p.step = stepWhereField
-		case stepWhereField:
+			p.step = stepCreateTableColumn
+		case stepCreateTableColumn:
 			identifier := p.peek()
 			if !isIdentifier(identifier) {
-				return p.query, fmt.Errorf("at WHERE: expected field")
-			}
-			p.query.Conditions = append(p.query.Conditions, query.Condition{Operand1: identifier, Operand1IsField: true})
-			p.pop()
-			p.step = stepWhereOperator
-		case stepWhereOperator:
-			operator := p.peek()
-			currentCondition := p.query.Conditions[len(p.query.Conditions)-1]
-			switch operator {
-			case "=":
-				currentCondition.Operator = query.Eq
-			case ">":
-				currentCondition.Operator = query.Gt
-			case ">=":
-				currentCondition.Operator = query.Gte
-			case "<":
-				currentCondition.Operator = query.Lt
-			case "<=":
-				currentCondition.Operator = query.Lte
-			case "!=":
-				currentCondition.Operator = query.Ne
-			default:
-				return p.query, fmt.Errorf("at WHERE: unknown operator")
+				return p.query, fmt.Errorf("at CREATE TABLE: expected column name")
 			}
-			p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
+			p.query.Columns = append(p.query.Columns, query.ColumnDef{Name: identifier})
 			p.pop()
-			p.step = stepWhereValue
-		case stepWhereValue:
-			currentCondition := p.query.Conditions[len(p.query.Conditions)-1]
-			identifier := p.peek()
-			if isIdentifier(identifier) {
-				currentCondition.Operand2 = identifier
-				currentCondition.Operand2IsField = true
-			} else {
-				quotedValue, ln := p.peekQuotedStringWithLength()
+			p.step = stepCreateTableColumnType
+		case stepCreateTableColumnType:
+			column := &p.query.Columns[len(p.query.Columns)-1]
+			columnType := p.peek()
+			if !isIdentifier(columnType) {
+				return p.query, fmt.Errorf("at CREATE TABLE: expected column type")
+			}
+			p.pop()
+			// The type's length or precision, e.g. VARCHAR(255) or DECIMAL(10, 2), may be lexed along with it or not
+			params, hasParams := "", false
+			if openingParens := strings.IndexByte(columnType, '('); openingParens != -1 {
+				if !strings.HasSuffix(columnType, ")") {
+					return p.query, fmt.Errorf("at CREATE TABLE: expected closing parens after column type length")
+				}
+				columnType, params, hasParams = columnType[:openingParens], columnType[openingParens+1:len(columnType)-1], true
+			} else if p.peek() == "(" {
+				closingParens := closingParensIndex(p.sql, p.i)
+				if closingParens == -1 {
+					return p.query, fmt.Errorf("at CREATE TABLE: expected closing parens after column type length")
+				}
+				params, hasParams = p.sql[p.i+1:closingParens], true
+				p.popLength(closingParens + 1 - p.i)
+			}
+			column.Type = columnType
+			if hasParams {
+				for _, param := range strings.Split(params, ",") {
+					n, ok := (&parser{sql: strings.TrimSpace(param)}).peekNonNegativeInteger()
+					if !ok || strconv.Itoa(n) != strings.TrimSpace(param) {
+						return p.query, fmt.Errorf("at CREATE TABLE: expected non-negative integers as column type length")
+					}
+					column.TypeParams = append(column.TypeParams, n)
+				}
+			}
+			p.step = stepCreateTableColumnModifierCommaOrClosingParens
+		case stepCreateTableColumnModifierCommaOrClosingParens:
+			column := &p.query.Columns[len(p.query.Columns)-1]
+			switch p.peek() {
+			case "NOT":
+				p.pop()
+				if p.peek() != "NULL" {
+					return p.query, fmt.Errorf("at CREATE TABLE: expected NULL after NOT")
+				}
+				column.NotNull = true
+				p.pop()
+			case "PRIMARY KEY":
+				column.PrimaryKey = true
+				p.pop()
+			case "DEFAULT":
+				p.pop()
+				value, valueType, ln := p.peekValueOrNullWithLength()
 				if ln == 0 {
-					return p.query, fmt.Errorf("at WHERE: expected quoted value")
+					return p.query, fmt.Errorf("at CREATE TABLE: expected quoted value, number or NULL after DEFAULT")
 				}
-				currentCondition.Operand2 = quotedValue
-				currentCondition.Operand2IsField = false
+				column.Default = value
+				column.DefaultType = valueType
+				p.popLength(ln)
+			case ",":
+				p.pop()
+				p.step = stepCreateTableColumn
+			case ")":
+				p.pop()
+				p.step = stepCreateTableAfterClosingParens
+			default:
+				return p.query, fmt.Errorf("at CREATE TABLE: expected NOT NULL, PRIMARY KEY, DEFAULT, comma or closing parens")
 			}
-			p.query.Conditions[len(p.query.Conditions)-1] = currentCondition
-			p.pop()
-			p.step = stepWhereAnd
-		case stepWhereAnd:
-			andRWord := p.peek()
-			if strings.ToUpper(andRWord) != "AND" {
-				return p.query, fmt.Errorf("expected AND")
+		case stepCreateTableAfterClosingParens:
+			return p.query, fmt.Errorf("at CREATE TABLE: unexpected token after closing parens")
+		case stepDropTableName:
+			schema, tableName, err := p.popTableName("DROP TABLE")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Schema = schema
+			p.query.TableName = tableName
+			p.step = stepDropTableAfterName
+		case stepDropTableAfterName:
+			return p.query, fmt.Errorf("at DROP TABLE: unexpected token after table name")
+		case stepTruncateTable:
+			schema, tableName, err := p.popTableName("TRUNCATE")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Schema = schema
+			p.query.TableName = tableName
+			p.step = stepTruncateAfterTable
+		case stepTruncateAfterTable:
+			return p.query, fmt.Errorf("at TRUNCATE: unexpected token after table name")
+		case stepDescribeTable:
+			schema, tableName, err := p.popTableName("DESCRIBE")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Schema = schema
+			p.query.TableName = tableName
+			p.step = stepDescribeAfterTable
+		case stepDescribeAfterTable:
+			return p.query, fmt.Errorf("at DESCRIBE: unexpected token after table name")
+		case stepShowKind:
+			switch p.peekReservedWord() {
+			case "TABLES":
+				p.query.ShowKind = query.ShowTables
+				p.step = stepShowEnd
+			case "COLUMNS":
+				p.query.ShowKind = query.ShowColumns
+				p.step = stepShowColumnsFrom
+			default:
+				return p.query, fmt.Errorf("at SHOW: unknown subcommand %s", p.peek())
 			}
 			p.pop()
-			p.step = stepWhereField
-		case stepInsertFieldsOpeningParens:
-			openingParens := p.peek()
-			if len(openingParens) != 1 || openingParens != "(" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected opening parens")
+		case stepShowColumnsFrom:
+			if p.peekReservedWord() != "FROM" {
+				return p.query, fmt.Errorf("at SHOW COLUMNS: expected FROM")
 			}
 			p.pop()
-			p.step = stepInsertFields
-		case stepInsertFields:
-			identifier := p.peek()
-			if !isIdentifier(identifier) {
-				return p.query, fmt.Errorf("at INSERT INTO: expected at least one field to insert")
+			p.step = stepShowColumnsTable
+		case stepShowColumnsTable:
+			schema, tableName, err := p.popTableName("SHOW COLUMNS")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Schema = schema
+			p.query.TableName = tableName
+			p.step = stepShowEnd
+		case stepShowEnd:
+			return p.query, fmt.Errorf("at SHOW: unexpected token after %s", showKindKeywords[p.query.ShowKind])
+		case stepCallProcName:
+			schema, procName, err := p.popTableName("CALL")
+			if err != nil {
+				return p.query, fmt.Errorf("at CALL: expected procedure name")
+			}
+			p.query.Schema = schema
+			p.query.ProcName = procName
+			p.step = stepCallOpeningParens
+		case stepCallOpeningParens:
+			if p.peek() != "(" {
+				return p.query, fmt.Errorf("at CALL: expected opening parens")
 			}
-			p.query.Fields = append(p.query.Fields, identifier)
 			p.pop()
-			p.step = stepInsertFieldsCommaOrClosingParens
-		case stepInsertFieldsCommaOrClosingParens:
+			p.step = stepCallArg
+			if p.peek() == ")" { // No arguments, e.g. CALL refresh()
+				p.step = stepCallCommaOrClosingParens
+			}
+		case stepCallArg:
+			value, valueType, ln := p.peekValueOrNullWithLength()
+			if placeholder, placeholderLn := p.peekPlaceholderWithLength(); placeholderLn > 0 {
+				value, valueType, ln = placeholder, query.OpPlaceholder, placeholderLn
+				p.addParam(placeholder)
+			}
+			if ln == 0 {
+				return p.query, fmt.Errorf("at CALL: expected quoted value, number or NULL")
+			}
+			p.query.Args = append(p.query.Args, value)
+			p.query.ArgTypes = append(p.query.ArgTypes, valueType)
+			p.popLength(ln)
+			p.step = stepCallCommaOrClosingParens
+		case stepCallCommaOrClosingParens:
 			commaOrClosingParens := p.peek()
 			if commaOrClosingParens != "," && commaOrClosingParens != ")" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected comma or closing parens")
+				return p.query, fmt.Errorf("at CALL: expected comma or closing parens")
 			}
 			p.pop()
-			if commaOrClosingParens == "," {
-				p.step = stepInsertFields
-				continue
+			p.step = stepCallArg
+			if commaOrClosingParens == ")" {
+				p.step = stepCallAfterClosingParens
 			}
-			p.step = stepInsertValuesRWord
-		case stepInsertValuesRWord:
-			valuesRWord := p.peek()
-			if strings.ToUpper(valuesRWord) != "VALUES" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected 'VALUES'")
+		case stepCallAfterClosingParens:
+			return p.query, fmt.Errorf("at CALL: unexpected token after closing parens")
+		case stepJoin:
+			joinType, ok := joinTypes[p.peek()]
+			if !ok {
+				return p.query, fmt.Errorf("expected JOIN")
 			}
+			p.query.Joins = append(p.query.Joins, query.Join{Type: joinType})
 			p.pop()
-			p.step = stepInsertValuesOpeningParens
-		case stepInsertValuesOpeningParens:
-			openingParens := p.peek()
-			if openingParens != "(" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected opening parens")
+			p.step = stepJoinTable
+		case stepJoinTable:
+			join := &p.query.Joins[len(p.query.Joins)-1]
+			schema, tableName, err := p.popTableName("JOIN")
+			if err != nil {
+				return p.query, err
+			}
+			join.Schema = schema
+			join.TableName = tableName
+			tableAlias, err := p.popTableAlias("JOIN")
+			if err != nil {
+				return p.query, err
+			}
+			join.TableAlias = tableAlias
+			p.step = stepJoinOn
+		case stepJoinOn:
+			onRWord := p.peek()
+			if onRWord != "ON" {
+				return p.query, fmt.Errorf("at JOIN: expected ON")
 			}
-			p.query.Inserts = append(p.query.Inserts, []string{})
 			p.pop()
-			p.step = stepInsertValues
-		case stepInsertValues:
-			quotedValue, ln := p.peekQuotedStringWithLength()
-			if ln == 0 {
-				return p.query, fmt.Errorf("at INSERT INTO: expected quoted value")
+			p.startConditions(&p.query.Joins[len(p.query.Joins)-1].On, "ON", stepJoin, nil)
+		case stepWhere:
+			if next, ok := p.nextClause(stepWhere); ok {
+				p.step = next
+				continue
+			}
+			if p.peekReservedWord() != "WHERE" {
+				return p.query, fmt.Errorf("expected WHERE")
 			}
-			p.query.Inserts[len(p.query.Inserts)-1] = append(p.query.Inserts[len(p.query.Inserts)-1], quotedValue)
 			p.pop()
-			p.step = stepInsertValuesCommaOrClosingParens
-		case stepInsertValuesCommaOrClosingParens:
-			commaOrClosingParens := p.peek()
-			if commaOrClosingParens != "," && commaOrClosingParens != ")" {
-				return p.query, fmt.Errorf("at INSERT INTO: expected comma or closing parens")
+			p.startConditions(&p.query.Conditions, "WHERE", stepWhere, &p.query.WhereExpr)
+		case stepConditionField, stepConditionOperator, stepConditionValue, stepConditionInOpeningParens,
+			stepConditionInValues, stepConditionInValuesCommaOrClosingParens, stepConditionBetweenLowerBound,
+			stepConditionBetweenAnd, stepConditionBetweenUpperBound, stepConditionLikePattern, stepConditionConnector:
+			if err := p.doParseCondition(); err != nil {
+				return p.query, err
+			}
+		case stepGroupBy:
+			groupByRWord := p.peek()
+			if groupByRWord != "GROUP BY" {
+				return p.query, fmt.Errorf("expected GROUP BY")
 			}
 			p.pop()
-			if commaOrClosingParens == "," {
-				p.step = stepInsertValues
+			p.step = stepGroupByField
+		case stepGroupByField:
+			identifier := p.peek()
+			if !isIdentifier(identifier) {
+				return p.query, fmt.Errorf("at GROUP BY: expected field to GROUP BY")
+			}
+			p.query.GroupBy = append(p.query.GroupBy, identifier)
+			p.pop()
+			p.step = stepGroupByComma
+		case stepGroupByComma:
+			commaRWord := p.peek()
+			if next, ok := p.nextClause(stepGroupBy); ok {
+				p.step = next
 				continue
 			}
-			currentInsertRow := p.query.Inserts[len(p.query.Inserts)-1]
-			if len(currentInsertRow) < len(p.query.Fields) {
-				return p.query, fmt.Errorf("at INSERT INTO: value count doesn't match field count")
+			if commaRWord != "," {
+				return p.query, fmt.Errorf("at GROUP BY: expected comma")
+			}
+			p.pop()
+			p.step = stepGroupByField
+		case stepHaving:
+			havingRWord := p.peek()
+			if havingRWord != "HAVING" {
+				return p.query, fmt.Errorf("expected HAVING")
 			}
-			p.step = stepInsertValuesCommaBeforeOpeningParens
-		case stepInsertValuesCommaBeforeOpeningParens:
+			if len(p.query.GroupBy) == 0 {
+				return p.query, fmt.Errorf("at HAVING: HAVING requires GROUP BY")
+			}
+			p.pop()
+			p.startConditions(&p.query.Having, "HAVING", stepHaving, nil)
+		case stepOrderBy:
+			orderByRWord := p.peek()
+			if orderByRWord != "ORDER BY" {
+				return p.query, fmt.Errorf("expected ORDER BY")
+			}
+			p.pop()
+			p.step = stepOrderByField
+		case stepOrderByField:
+			identifier := p.peek()
+			orderByField := query.OrderByField{Field: identifier}
+			if number, ln := p.peekNumberWithLength(); ln > 0 {
+				ordinal, err := strconv.Atoi(number)
+				if err != nil || ordinal <= 0 {
+					return p.query, fmt.Errorf("at ORDER BY: expected ordinal to be a positive integer")
+				}
+				orderByField = query.OrderByField{Ordinal: ordinal}
+			} else if !isIdentifier(identifier) {
+				return p.query, fmt.Errorf("at ORDER BY: expected field to ORDER BY")
+			}
+			p.pop()
+			switch p.peek() {
+			case "ASC":
+				p.pop()
+			case "DESC":
+				orderByField.Direction = query.Desc
+				p.pop()
+			}
+			switch p.peek() {
+			case "NULLS FIRST":
+				orderByField.Nulls = query.NullsFirst
+				p.pop()
+			case "NULLS LAST":
+				orderByField.Nulls = query.NullsLast
+				p.pop()
+			case "NULLS":
+				p.pop()
+				return p.query, fmt.Errorf("at ORDER BY: expected FIRST or LAST after NULLS")
+			}
+			p.query.OrderBy = append(p.query.OrderBy, orderByField)
+			p.step = stepOrderByComma
+		case stepOrderByComma:
 			commaRWord := p.peek()
-			if strings.ToUpper(commaRWord) != "," {
-				return p.query, fmt.Errorf("at INSERT INTO: expected comma")
+			if next, ok := p.nextClause(stepOrderBy); ok {
+				p.step = next
+				continue
+			}
+			if commaRWord != "," {
+				return p.query, fmt.Errorf("at ORDER BY: expected ASC, DESC or comma")
 			}
 			p.pop()
-			p.step = stepInsertValuesOpeningParens
-		}
-	}
-}
-
-func (p *parser) peek() string {
+			p.step = stepOrderByField
+		case stepLimit:
+			limitRWord := p.peek()
+			if limitRWord != "LIMIT" {
+				return p.query, fmt.Errorf("expected LIMIT")
+			}
+			if p.query.Top {
+				return p.query, fmt.Errorf("at LIMIT: limit already specified in TOP")
+			}
+			p.pop()
+			p.step = stepLimitValue
+		case stepLimitValue:
+			limit, ok := p.peekNonNegativeInteger()
+			if !ok {
+				return p.query, fmt.Errorf("at LIMIT: expected non-negative integer")
+			}
+			p.query.Limit = &limit
+			p.pop()
+			p.step = stepLimitComma
+		case stepLimitComma:
+			commaRWord := p.peek()
+			if next, ok := p.nextClause(stepLimit); ok {
+				p.step = next
+				continue
+			}
+			if p.query.Type != query.Select {
+				return p.query, fmt.Errorf("at LIMIT: unexpected token after LIMIT")
+			}
+			if commaRWord != "," || p.query.Offset != nil {
+				return p.query, fmt.Errorf("at LIMIT: expected OFFSET")
+			}
+			// MySQL's "LIMIT offset, limit" shorthand: the value read so far is actually the offset
+			p.query.Offset = p.query.Limit
+			p.query.Limit = nil
+			p.pop()
+			p.step = stepLimitValue
+		case stepOffset:
+			offsetRWord := p.peek()
+			if offsetRWord != "OFFSET" {
+				return p.query, fmt.Errorf("expected OFFSET")
+			}
+			if p.query.Offset != nil {
+				return p.query, fmt.Errorf("at OFFSET: offset already specified in LIMIT")
+			}
+			p.pop()
+			p.step = stepOffsetValue
+		case stepOffsetValue:
+			offset, ok := p.peekNonNegativeInteger()
+			if !ok {
+				return p.query, fmt.Errorf("at OFFSET: expected non-negative integer")
+			}
+			p.query.Offset = &offset
+			p.pop()
+			p.step = stepAfterOffset
+		case stepAfterOffset:
+			if next, ok := p.nextClause(stepOffset); ok {
+				p.step = next
+				continue
+			}
+			return p.query, fmt.Errorf("at OFFSET: unexpected token after OFFSET")
+		case stepReturning:
+			returningRWord := p.peek()
+			if returningRWord != "RETURNING" {
+				return p.query, fmt.Errorf("expected RETURNING")
+			}
+			p.pop()
+			p.step = stepReturningField
+		case stepReturningField:
+			if !p.isFieldAhead() {
+				return p.query, fmt.Errorf("at RETURNING: expected field to return")
+			}
+			field, _, err := p.popField("RETURNING")
+			if err != nil {
+				return p.query, err
+			}
+			p.query.Returning = append(p.query.Returning, field)
+			p.step = stepReturningComma
+		case stepReturningComma:
+			commaRWord := p.peek()
+			if commaRWord != "," {
+				return p.query, fmt.Errorf("at RETURNING: expected comma")
+			}
+			p.pop()
+			p.step = stepReturningField
+		case stepUnion:
+			unionRWord := p.peek()
+			p.pop()
+			if p.peekReservedWord() != "SELECT" {
+				return p.query, fmt.Errorf("at %s: expected SELECT", unionRWord)
+			}
+			union, err := p.parseNestedQuery()
+			p.query.Union = &query.Union{All: unionRWord == "UNION ALL", Query: union}
+			if err != nil {
+				return p.query, err
+			}
+		case stepInsertFieldsOpeningParens:
+			openingParens := p.peek()
+			if len(openingParens) != 1 || openingParens != "(" {
+				return p.query, fmt.Errorf("at %s: expected opening parens", p.insertRWord())
+			}
+			p.pop()
+			p.step = stepInsertFields
+		case stepInsertFields:
+			identifier := p.peek()
+			if !isIdentifier(identifier) {
+				return p.query, fmt.Errorf("at %s: expected at least one field to insert", p.insertRWord())
+			}
+			p.query.Fields = append(p.query.Fields, identifier)
+			p.pop()
+			p.step = stepInsertFieldsCommaOrClosingParens
+		case stepInsertFieldsCommaOrClosingParens:
+			commaOrClosingParens := p.peek()
+			if commaOrClosingParens != "," && commaOrClosingParens != ")" {
+				return p.query, fmt.Errorf("at %s: expected comma or closing parens", p.insertRWord())
+			}
+			p.pop()
+			if commaOrClosingParens == "," {
+				p.step = stepInsertFields
+				continue
+			}
+			p.step = stepInsertValuesRWord
+		case stepInsertValuesRWord:
+			valuesRWord := p.peekReservedWord()
+			if valuesRWord == "SELECT" {
+				insertSelect, err := p.parseNestedQuery()
+				p.query.InsertSelect = &insertSelect
+				if err != nil {
+					return p.query, err
+				}
+				continue
+			}
+			if valuesRWord != "VALUES" {
+				return p.query, fmt.Errorf("at %s: expected 'VALUES'", p.insertRWord())
+			}
+			p.pop()
+			p.step = stepInsertValuesOpeningParens
+		case stepInsertValuesOpeningParens:
+			openingParens := p.peek()
+			if openingParens != "(" {
+				return p.query, fmt.Errorf("at %s: expected opening parens", p.insertRWord())
+			}
+			p.query.Inserts = append(p.query.Inserts, []string{})
+			p.query.InsertTypes = append(p.query.InsertTypes, []query.OperandType{})
+			p.pop()
+			p.step = stepInsertValues
+		case stepInsertValues:
+			value, valueType, ln := p.peekValueOrNullWithLength()
+			if placeholder, placeholderLn := p.peekPlaceholderWithLength(); placeholderLn > 0 {
+				value, valueType, ln = placeholder, query.OpPlaceholder, placeholderLn
+				p.addParam(placeholder)
+			}
+			if ln == 0 {
+				return p.query, fmt.Errorf("at %s: expected quoted value, number or NULL", p.insertRWord())
+			}
+			p.query.Inserts[len(p.query.Inserts)-1] = append(p.query.Inserts[len(p.query.Inserts)-1], value)
+			p.query.InsertTypes[len(p.query.InsertTypes)-1] = append(p.query.InsertTypes[len(p.query.InsertTypes)-1], valueType)
+			p.pop()
+			p.step = stepInsertValuesCommaOrClosingParens
+		case stepInsertValuesCommaOrClosingParens:
+			commaOrClosingParens := p.peek()
+			if commaOrClosingParens != "," && commaOrClosingParens != ")" {
+				return p.query, fmt.Errorf("at %s: expected comma or closing parens", p.insertRWord())
+			}
+			if commaOrClosingParens == "," {
+				p.pop()
+				p.step = stepInsertValues
+				continue
+			}
+			// It's checked before popping the closing parens, so that the error points at the end of the row
+			if len(p.query.Inserts[len(p.query.Inserts)-1]) != len(p.query.Fields) {
+				return p.query, p.valueCountError(len(p.query.Inserts))
+			}
+			p.pop()
+			p.step = stepInsertValuesCommaBeforeOpeningParens
+		case stepInsertValuesCommaBeforeOpeningParens:
+			commaRWord := p.peekReservedWord()
+			if commaRWord == "ON DUPLICATE KEY UPDATE" {
+				p.query.OnDuplicate = map[string]string{}
+				p.query.OnDuplicateTypes = map[string]query.OperandType{}
+				p.updates, p.updateTypes, p.updatesRWord = p.query.OnDuplicate, p.query.OnDuplicateTypes, commaRWord
+				p.pop()
+				p.step = stepUpdateField
+				continue
+			}
+			if next, ok := p.nextClause(stepInsertValuesCommaBeforeOpeningParens); ok {
+				p.step = next
+				continue
+			}
+			if commaRWord != "," {
+				return p.query, fmt.Errorf("at %s: expected comma", p.insertRWord())
+			}
+			p.pop()
+			p.step = stepInsertValuesOpeningParens
+		}
+	}
+}
+
+// insertRWord returns the reserved word that started the query, for the errors of the steps that INSERT INTO and
+// REPLACE INTO share
+func (p *parser) insertRWord() string {
+	if p.query.Type == query.Replace {
+		return "REPLACE INTO"
+	}
+	return "INSERT INTO"
+}
+
+// isFieldAhead reports whether the current token may start a field to SELECT or return, i.e. a field, * or a literal
+func (p *parser) isFieldAhead() bool {
+	_, _, ln := p.peekValueOrNullWithLength()
+	return ln > 0 || isIdentifierOrAsterisk(p.peek())
+}
+
+// popField pops a field to SELECT or return, which may be an arithmetic expression. It returns the field as it goes in
+// Fields, along with its source text, which unlike the former keeps quoted strings quoted.
+func (p *parser) popField(rWord string) (string, string, error) {
+	field, ln := p.peekWithLength()
+	if _, _, valueLn := p.peekValueOrNullWithLength(); valueLn == 0 {
+		field = withoutInnerOperator(field, "*")
+		ln = len(field)
+	}
+	start, text := p.i, p.sql[p.i:p.i+ln]
+	p.popLength(ln)
+	expression, err := p.popArithmetic(start, start+ln, rWord, "*")
+	if err != nil {
+		return "", "", err
+	}
+	if expression != "" {
+		return expression, expression, nil
+	}
+	return field, text, nil
+}
+
+// parseNestedQuery parses the rest of the SQL as a query on its own, e.g. the SELECT in INSERT INTO ... SELECT.
+// Its placeholders are added to the Params of the outer query, so that they're all in one place and numbered in order.
+func (p *parser) parseNestedQuery() (query.Query, error) {
+	return p.parseNestedQueryUntil(len(p.sql), nil)
+}
+
+// parseSubquery parses the SELECT within the parens at the current position, e.g. the one in EXISTS (SELECT ...),
+// popping it along with its parens. Its fields may be qualified with the tables of the enclosing query.
+func (p *parser) parseSubquery(rWord string) (*query.Query, error) {
+	closingParens := closingParensIndex(p.sql, p.i)
+	if closingParens == -1 {
+		return nil, fmt.Errorf("at %s: expected closing parens after subquery", rWord)
+	}
+	p.popLength(1)
+	if p.peekReservedWord() != "SELECT" {
+		return nil, fmt.Errorf("at %s: expected SELECT in subquery", rWord)
+	}
+	q, err := p.parseNestedQueryUntil(closingParens, p)
+	if err != nil {
+		return nil, err
+	}
+	if p.semicolonPos != 0 {
+		return nil, fmt.Errorf("at %s: unexpected semicolon in subquery", rWord)
+	}
+	p.popLength(closingParens + 1 - p.i)
+	return &q, nil
+}
+
+// isSubqueryAhead reports whether there's a parenthesized SELECT at the current position, e.g. the one in IN (SELECT ...)
+func (p *parser) isSubqueryAhead() bool {
+	if p.peek() != "(" {
+		return false
+	}
+	afterParens := &parser{sql: p.sql, i: p.i + 1, options: p.options}
+	afterParens.popWhitespace()
+	return afterParens.peekReservedWord() == "SELECT"
+}
+
+// parseNestedQueryUntil parses the SQL up to end as a query on its own, with the given parser as its outer one, if any
+func (p *parser) parseNestedQueryUntil(end int, outer *parser) (query.Query, error) {
+	nested := &parser{ctx: p.ctx, sql: p.sql[p.i:end], offset: p.offset + p.i, step: stepType, options: p.options, query: query.Query{Params: p.query.Params}, outer: outer}
+	q, err := nested.doParse()
+	if err == nil {
+		err = nested.validate()
+	}
+	if nested.semicolonPos != 0 {
+		p.semicolonPos = p.i + nested.semicolonPos
+	}
+	p.i += nested.i
+	p.query.Params, q.Params = q.Params, nil
+	return q, err
+}
+
+// popArithmetic pops the rest of an arithmetic expression whose first operand, from start to end, was just popped,
+// e.g. "+ '1'" in "counter + '1'". Its operands are cut at the given operators, as in withoutInnerOperator. It returns
+// the whole expression as written, e.g. counter+'1', or "" if there's no arithmetic.
+func (p *parser) popArithmetic(start, end int, rWord, operators string) (string, error) {
+	expression := ""
+	for p.i < len(p.sql) && strings.IndexByte("+-*/", p.sql[p.i]) != -1 {
+		p.popLength(1)
+		_, _, ln := p.peekValueWithLength()
+		if ln == 0 {
+			operand := withoutInnerOperator(p.peek(), operators)
+			if !isIdentifier(operand) {
+				return "", fmt.Errorf("at %s: expected field or value after arithmetic operator", rWord)
+			}
+			ln = len(operand)
+		}
+		end = p.i + ln
+		p.popLength(ln)
+		expression = p.sql[start:end]
+	}
+	return expression, nil
+}
+
+// withoutInnerOperator cuts an identifier at the first of the given operators within it, i.e. hyphens and asterisks,
+// for where they're arithmetic rather than part of the identifier, e.g. counter-1 in an UPDATE SET value, or a*2 in a
+// SELECTed field, whose hyphenated identifiers are kept whole like in conditions. The asterisk of e.g. b.* and the
+// hyphens and asterisks within function calls and quoted identifiers are kept.
+func withoutInnerOperator(identifier, operators string) string {
+	for i := 0; i < len(identifier); i++ {
+		if i > 0 && strings.IndexByte(operators, identifier[i]) != -1 && (identifier[i] != '*' || identifier[i-1] != '.') {
+			return identifier[:i]
+		}
+		if !isWordByte(identifier[i]) && identifier[i] != '.' {
+			return identifier
+		}
+	}
+	return identifier
+}
+
+// nextClause returns the step that parses the clause starting at the current token, as long as that clause may
+// appear after the one parsed by the current step, e.g. ORDER BY after WHERE. Any clause may follow a step that
+// isn't a clause, e.g. the one that parses the table name.
+func (p *parser) nextClause(current step) (step, bool) {
+	rWord := p.peekReservedWord()
+	currentIndex := -1
+	for i, c := range clauses[p.query.Type] {
+		if c.step == current {
+			currentIndex = i
+		}
+	}
+	for i, c := range clauses[p.query.Type] {
+		if c.rWord == rWord && (i > currentIndex || c.step == current && current == stepJoin) {
+			return c.step, true
+		}
+	}
+	return 0, false
+}
+
+// doParseCondition parses a step of a list of conditions, e.g. the ones in a WHERE clause
+func (p *parser) doParseCondition() error {
+	switch p.step {
+	case stepConditionField:
+		identifier := p.peek()
+		if identifier == "NOT" {
+			p.conditionNegated = !p.conditionNegated
+			p.pop()
+			return nil
+		}
+		if identifier == "(" && p.conditionsExpr != nil {
+			if p.conditionNegated {
+				p.conditionsTokens = append(p.conditionsTokens, negatedOpeningParensToken)
+			} else {
+				p.conditionsTokens = append(p.conditionsTokens, openingParensToken)
+			}
+			p.conditionNegated = false
+			p.conditionsDepth++
+			p.pop()
+			return nil
+		}
+		if identifier == "EXISTS" {
+			condition := query.Condition{Operator: query.Exists, Pos: p.offset + p.i}
+			if p.conditionNegated {
+				condition.Operator = query.NotExists
+			}
+			p.pop()
+			if p.peek() != "(" {
+				return fmt.Errorf("at %s: expected opening parens after EXISTS", p.conditionsRWord)
+			}
+			subquery, err := p.parseSubquery(p.conditionsRWord)
+			if err != nil {
+				return err
+			}
+			condition.Subquery = subquery
+			*p.conditions = append(*p.conditions, condition)
+			p.conditionNegated = false
+			p.conditionsTokens = append(p.conditionsTokens, len(*p.conditions)-1)
+			p.step = stepConditionConnector
+			return nil
+		}
+		if !isIdentifier(identifier) {
+			return fmt.Errorf("at %s: expected field", p.conditionsRWord)
+		}
+		if err := p.validateFieldName(identifier); err != nil {
+			return err
+		}
+		*p.conditions = append(*p.conditions, query.Condition{Operand1: identifier, Operand1IsField: true, Negated: p.conditionNegated, Pos: p.offset + p.i})
+		p.conditionNegated = false
+		p.conditionsTokens = append(p.conditionsTokens, len(*p.conditions)-1)
+		p.pop()
+		p.step = stepConditionOperator
+	case stepConditionOperator:
+		operator := p.peek()
+		currentCondition := p.currentCondition()
+		switch operator {
+		case "=":
+			currentCondition.Operator = query.Eq
+		case ">":
+			currentCondition.Operator = query.Gt
+		case ">=":
+			currentCondition.Operator = query.Gte
+		case "<":
+			currentCondition.Operator = query.Lt
+		case "<=":
+			currentCondition.Operator = query.Lte
+		case "!=", "<>":
+			currentCondition.Operator = query.Ne
+		case "IN":
+			currentCondition.Operator = query.In
+			currentCondition.Operand2Type = query.OpList
+		case "NOT":
+			p.pop()
+			switch p.peek() {
+			case "IN":
+				currentCondition.Operator = query.NotIn
+				currentCondition.Operand2Type = query.OpList
+			case "LIKE":
+				currentCondition.Operator = query.NotLike
+			default:
+				return fmt.Errorf("at %s: expected IN or LIKE after NOT", p.conditionsRWord)
+			}
+		case "BETWEEN":
+			currentCondition.Operator = query.Between
+		case "LIKE":
+			currentCondition.Operator = query.Like
+		case "IS":
+			p.pop()
+			if p.peek() == "NOT" {
+				p.pop()
+				currentCondition.Operator = query.IsNotNull
+			} else {
+				currentCondition.Operator = query.IsNull
+			}
+			if p.peek() != "NULL" {
+				return fmt.Errorf("at %s: expected NULL or NOT NULL after IS", p.conditionsRWord)
+			}
+		default:
+			return fmt.Errorf("at %s: unknown operator", p.conditionsRWord)
+		}
+		p.pop()
+		if currentCondition.Operand2Type == query.OpList {
+			p.step = stepConditionInOpeningParens
+			return nil
+		}
+		if currentCondition.Operator == query.Between {
+			p.step = stepConditionBetweenLowerBound
+			return nil
+		}
+		if currentCondition.Operator == query.Like || currentCondition.Operator == query.NotLike {
+			p.step = stepConditionLikePattern
+			return nil
+		}
+		if currentCondition.Operator.IsUnary() {
+			p.step = stepConditionConnector
+			return nil
+		}
+		p.step = stepConditionValue
+	case stepConditionValue:
+		currentCondition := p.currentCondition()
+		if p.peek() == "(" {
+			subquery, err := p.parseSubquery(p.conditionsRWord)
+			if err != nil {
+				return err
+			}
+			currentCondition.Subquery = subquery
+			currentCondition.Operand2Type = query.OpSubquery
+			p.step = stepConditionConnector
+			return nil
+		}
+		if value, valueType, ln := p.peekValueOrNullWithLength(); ln > 0 {
+			currentCondition.Operand2 = value
+			currentCondition.Operand2IsField = false
+			currentCondition.Operand2Type = valueType
+		} else if placeholder, ln := p.peekPlaceholderWithLength(); ln > 0 {
+			currentCondition.Operand2 = placeholder
+			currentCondition.Operand2IsField = false
+			currentCondition.Operand2Type = query.OpPlaceholder
+			p.addParam(placeholder)
+		} else {
+			identifier := p.peek()
+			if !isIdentifier(identifier) {
+				return fmt.Errorf("at %s: expected quoted value", p.conditionsRWord)
+			}
+			if err := p.validateFieldName(identifier); err != nil {
+				return err
+			}
+			currentCondition.Operand2 = identifier
+			currentCondition.Operand2IsField = true
+			currentCondition.Operand2Type = query.OpField
+		}
+		p.pop()
+		p.step = stepConditionConnector
+	case stepConditionInOpeningParens:
+		openingParens := p.peek()
+		if openingParens != "(" {
+			return fmt.Errorf("at %s: expected opening parens after IN", p.conditionsRWord)
+		}
+		if p.isSubqueryAhead() {
+			subquery, err := p.parseSubquery(p.conditionsRWord)
+			if err != nil {
+				return err
+			}
+			currentCondition := p.currentCondition()
+			currentCondition.Subquery = subquery
+			currentCondition.Operand2Type = query.OpSubquery
+			p.step = stepConditionConnector
+			return nil
+		}
+		p.pop()
+		p.step = stepConditionInValues
+	case stepConditionInValues:
+		currentCondition := p.currentCondition()
+		value, valueType, ln := p.peekValueWithLength()
+		if ln == 0 {
+			if len(currentCondition.Operand2List) == 0 && p.peek() == ")" {
+				return fmt.Errorf("at %s: empty IN list", p.conditionsRWord)
+			}
+			return fmt.Errorf("at %s: expected quoted value or number in IN list", p.conditionsRWord)
+		}
+		currentCondition.Operand2List = append(currentCondition.Operand2List, value)
+		currentCondition.Operand2ListTypes = append(currentCondition.Operand2ListTypes, valueType)
+		p.pop()
+		p.step = stepConditionInValuesCommaOrClosingParens
+	case stepConditionInValuesCommaOrClosingParens:
+		commaOrClosingParens := p.peek()
+		if commaOrClosingParens != "," && commaOrClosingParens != ")" {
+			return fmt.Errorf("at %s: expected comma or closing parens in IN list", p.conditionsRWord)
+		}
+		p.pop()
+		if commaOrClosingParens == "," {
+			p.step = stepConditionInValues
+			return nil
+		}
+		p.step = stepConditionConnector
+	case stepConditionBetweenLowerBound:
+		currentCondition := p.currentCondition()
+		value, valueType, ln := p.peekValueWithLength()
+		if placeholder, placeholderLn := p.peekPlaceholderWithLength(); placeholderLn > 0 {
+			value, valueType, ln = placeholder, query.OpPlaceholder, placeholderLn
+			p.addParam(placeholder)
+		}
+		if ln == 0 {
+			return fmt.Errorf("at %s: expected quoted value or number as BETWEEN lower bound", p.conditionsRWord)
+		}
+		currentCondition.Operand2 = value
+		currentCondition.Operand2Type = valueType
+		p.pop()
+		p.step = stepConditionBetweenAnd
+	case stepConditionBetweenAnd:
+		andRWord := p.peek()
+		if andRWord != "AND" {
+			return fmt.Errorf("at %s: expected AND after BETWEEN lower bound", p.conditionsRWord)
+		}
+		p.pop()
+		p.step = stepConditionBetweenUpperBound
+	case stepConditionBetweenUpperBound:
+		currentCondition := p.currentCondition()
+		value, valueType, ln := p.peekValueWithLength()
+		if placeholder, placeholderLn := p.peekPlaceholderWithLength(); placeholderLn > 0 {
+			value, valueType, ln = placeholder, query.OpPlaceholder, placeholderLn
+			p.addParam(placeholder)
+		}
+		if ln == 0 {
+			return fmt.Errorf("at %s: expected quoted value or number as BETWEEN upper bound", p.conditionsRWord)
+		}
+		currentCondition.Operand3 = value
+		currentCondition.Operand3Type = valueType
+		p.pop()
+		p.step = stepConditionConnector
+	case stepConditionLikePattern:
+		currentCondition := p.currentCondition()
+		pattern, ln := p.peekQuotedStringWithLength()
+		patternType := query.OpQuoted
+		if placeholder, placeholderLn := p.peekPlaceholderWithLength(); placeholderLn > 0 {
+			pattern, patternType, ln = placeholder, query.OpPlaceholder, placeholderLn
+			p.addParam(placeholder)
+		}
+		if ln == 0 {
+			return fmt.Errorf("at %s: expected quoted pattern after LIKE", p.conditionsRWord)
+		}
+		currentCondition.Operand2 = pattern
+		currentCondition.Operand2Type = patternType
+		p.pop()
+		if p.peek() == "ESCAPE" {
+			p.pop()
+			escape, escapeLn := p.peekEscapeCharacterWithLength()
+			if escapeLn == 0 || utf8.RuneCountInString(escape) != 1 {
+				return fmt.Errorf("at %s: expected quoted single character after ESCAPE", p.conditionsRWord)
+			}
+			currentCondition.Escape = escape
+			p.popLength(escapeLn)
+		}
+		p.step = stepConditionConnector
+	case stepConditionConnector:
+		connectorRWord := p.peek()
+		if connectorRWord == ")" && p.conditionsExpr != nil {
+			if p.conditionsDepth == 0 {
+				return fmt.Errorf("at %s: unexpected closing parens", p.conditionsRWord)
+			}
+			p.conditionsTokens = append(p.conditionsTokens, closingParensToken)
+			p.conditionsDepth--
+			p.pop()
+			return nil
+		}
+		if connectorRWord == "COLLATE" && p.canCollate() {
+			p.pop()
+			collation := p.peek()
+			if !isIdentifier(collation) {
+				return fmt.Errorf("at %s: expected collation name after COLLATE", p.conditionsRWord)
+			}
+			p.currentCondition().Collation = collation
+			p.pop()
+			return nil
+		}
+		switch connectorRWord {
+		case "AND":
+		case "OR":
+			p.currentCondition().OrWithNext = true
+		default:
+			if next, ok := p.nextClause(p.conditionsClause); ok {
+				p.step = next
+				return p.endConditions()
+			}
+			return fmt.Errorf("expected AND or OR")
+		}
+		p.pop()
+		p.step = stepConditionField
+	}
+	return nil
+}
+
+// startTables starts parsing a comma-separated list of table names into the given slice, e.g. the ones after USING
+func (p *parser) startTables(tables *[]string, rWord string, clause step) {
+	p.tables = tables
+	p.tablesRWord = rWord
+	p.tablesClause = clause
+	p.step = stepTablesTable
+}
+
+// startConditions starts parsing a list of conditions into the given slice. If expr isn't nil, conditions may be
+// grouped with parens, and expr gets their expression tree when they are.
+func (p *parser) startConditions(conditions *[]query.Condition, rWord string, clause step, expr **query.WhereExpr) {
+	p.conditions = conditions
+	p.conditionsRWord = rWord
+	p.conditionsClause = clause
+	p.conditionsExpr = expr
+	p.conditionsTokens = nil
+	p.conditionsDepth = 0
+	p.conditionNegated = false
+	p.step = stepConditionField
+}
+
+// endConditions finishes parsing a list of conditions, building their expression tree if they're grouped with parens
+func (p *parser) endConditions() error {
+	if p.conditionsDepth > 0 {
+		return fmt.Errorf("at %s: expected closing parens", p.conditionsRWord)
+	}
+	for _, token := range p.conditionsTokens {
+		if token == openingParensToken || token == negatedOpeningParensToken {
+			expr, _ := (&whereExprBuilder{tokens: p.conditionsTokens, conditions: *p.conditions}).buildOr()
+			*p.conditionsExpr = &expr
+			break
+		}
+	}
+	return nil
+}
+
+// canCollate reports whether the condition that was just parsed may be followed by COLLATE, i.e. it has a value to
+// compare with, it has no collation yet and it's not wrapped in parens
+func (p *parser) canCollate() bool {
+	condition := p.currentCondition()
+	lastToken := p.conditionsTokens[len(p.conditionsTokens)-1]
+	return condition.Collation == "" && condition.Operand2Type != query.UnknownOperandType && lastToken != closingParensToken
+}
+
+func (p *parser) isAfterOpeningParens() bool {
+	lastToken := p.conditionsTokens[len(p.conditionsTokens)-1]
+	return lastToken == openingParensToken || lastToken == negatedOpeningParensToken
+}
+
+// whereExprBuilder builds the expression tree of a list of conditions from the order in which they and the parens
+// grouping them appeared, where AND binds tighter than OR
+type whereExprBuilder struct {
+	tokens     []int
+	conditions []query.Condition
+	i          int
+}
+
+// buildOr builds the expression up to the end or up to an unmatched closing parens, also returning the index of
+// the last condition in it, whose OrWithNext is the connector after the expression
+func (b *whereExprBuilder) buildOr() (query.WhereExpr, int) {
+	orOperands, andOperands := []query.WhereExpr{}, []query.WhereExpr{}
+	for {
+		operand, last := b.buildOperand()
+		andOperands = append(andOperands, operand)
+		isEnd := b.i >= len(b.tokens) || b.tokens[b.i] == closingParensToken
+		if isEnd || b.conditions[last].OrWithNext {
+			orOperands = append(orOperands, newWhereExpr(query.And, andOperands))
+			andOperands = []query.WhereExpr{}
+		}
+		if isEnd {
+			return newWhereExpr(query.Or, orOperands), last
+		}
+	}
+}
+
+func (b *whereExprBuilder) buildOperand() (query.WhereExpr, int) {
+	token := b.tokens[b.i]
+	b.i++
+	if token != openingParensToken && token != negatedOpeningParensToken {
+		condition := b.conditions[token]
+		return query.WhereExpr{Type: query.Leaf, Condition: &condition}, token
+	}
+	expr, last := b.buildOr()
+	b.i++ // The closing parens
+	return query.WhereExpr{Type: query.Group, Children: []query.WhereExpr{expr}, Negated: token == negatedOpeningParensToken}, last
+}
+
+// newWhereExpr returns an expression of type t with the given operands, or the operand itself if there's only one
+func newWhereExpr(t query.WhereExprType, operands []query.WhereExpr) query.WhereExpr {
+	if len(operands) == 1 {
+		return operands[0]
+	}
+	return query.WhereExpr{Type: t, Children: operands}
+}
+
+// validateFunctions checks that the functions that a SELECTed field calls, if any, are among the Functions option
+func (p *parser) validateFunctions(expr query.FieldExpr) error {
+	if len(p.options.Functions) == 0 {
+		return nil
+	}
+	if expr.Type == query.FunctionCall {
+		known := false
+		for _, function := range p.options.Functions {
+			known = known || strings.EqualFold(expr.Name, function)
+		}
+		if !known {
+			return fmt.Errorf("at SELECT: unknown function %s", expr.Name)
+		}
+	}
+	for _, arg := range expr.Args {
+		if err := p.validateFunctions(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateFieldName checks that a field in a condition that's qualified with its table (and maybe its schema), e.g.
+// users.id, has neither empty parts nor more than three. When the query has a table alias or joins, the table must
+// also be one of the query's, by name or by alias. Unqualified fields and function calls are always valid.
+func (p *parser) validateFieldName(field string) error {
+	if !strings.Contains(field, ".") || strings.ContainsAny(field, "(*") {
+		return nil
+	}
+	parts := strings.Split(field, ".")
+	for _, part := range parts {
+		if part == "" {
+			return fmt.Errorf("at %s: empty part in field %s", p.conditionsRWord, field)
+		}
+	}
+	if len(parts) > 3 {
+		return fmt.Errorf("at %s: expected field to have at most three parts, i.e. schema.table.column", p.conditionsRWord)
+	}
+	if table := strings.Join(parts[:len(parts)-1], "."); !p.isKnownTable(table) {
+		return fmt.Errorf("at %s: unknown table or alias %s in field %s", p.conditionsRWord, table, field)
+	}
+	return nil
+}
+
+// isKnownTable reports whether table may qualify a field, i.e. it's the query's table or a JOINed one or, in a
+// subquery, one of the enclosing query's. Any table may if the query has neither an alias nor JOINs.
+func (p *parser) isKnownTable(table string) bool {
+	if p.query.TableAlias == "" && len(p.query.Joins) == 0 {
+		return true
+	}
+	if isQueryTable(table, p.query.Schema, p.query.TableName, p.query.TableAlias) {
+		return true
+	}
+	for _, j := range p.query.Joins {
+		if isQueryTable(table, j.Schema, j.TableName, j.TableAlias) {
+			return true
+		}
+	}
+	return p.outer != nil && p.outer.isKnownTable(table)
+}
+
+// isQueryTable reports whether table, which qualifies a field, refers to the table with the given schema, name and alias
+func isQueryTable(table, schema, tableName, tableAlias string) bool {
+	return table == tableName || (tableAlias != "" && table == tableAlias) || (schema != "" && table == schema+"."+tableName)
+}
+
+func (p *parser) currentCondition() *query.Condition {
+	return &(*p.conditions)[len(*p.conditions)-1]
+}
+
+func (p *parser) peek() string {
 	peeked, _ := p.peekWithLength()
 	return peeked
 }
 
+// peekReservedWord peeks the next token if it's a reserved word, e.g. SELECT, or "" otherwise. Unlike peek, it never
+// mistakes a quoted string for a reserved word, e.g. 'select', whose value it would otherwise peek as is.
+func (p *parser) peekReservedWord() string {
+	token, _ := p.peekTokenWithLength()
+	if token.Kind != KeywordToken && token.Kind != SymbolToken {
+		return ""
+	}
+	return token.Value
+}
+
 func (p *parser) pop() string {
 	peeked, len := p.peekWithLength()
+	p.popLength(len)
+	return peeked
+}
+
+func (p *parser) popLength(len int) {
 	p.i += len
 	p.popWhitespace()
-	return peeked
 }
 
+// popWhitespace pops whitespace, along with comments, which are parsed as if they were whitespace
 func (p *parser) popWhitespace() {
-	for ; p.i < len(p.sql) && p.sql[p.i] == ' '; p.i++ {
+	for p.i < len(p.sql) {
+		if isWhitespace(p.sql[p.i]) {
+			p.i++
+		} else if ln := commentLength(p.sql, p.i); ln > 0 && !isUnterminatedComment(p.sql, p.i) {
+			p.i += ln
+		} else {
+			return
+		}
+	}
+}
+
+// commentLength returns the length of the comment at sql[i:], i.e. from -- until the end of the line or from /*
+// until */, or 0 if there's none there. A block comment without */ extends until the end of sql, so that a script
+// that's being read may go on, but it's not whitespace to the parser, which fails on it.
+func commentLength(sql string, i int) int {
+	if strings.HasPrefix(sql[i:], "--") {
+		if end := strings.IndexByte(sql[i:], '\n'); end != -1 {
+			return end + 1
+		}
+		return len(sql) - i
+	}
+	if strings.HasPrefix(sql[i:], "/*") {
+		if end := strings.Index(sql[i+2:], "*/"); end != -1 {
+			return end + 4
+		}
+		return len(sql) - i
+	}
+	return 0
+}
+
+// isUnterminatedComment reports whether there's a block comment at sql[i:] without */, which would otherwise hide
+// that the SQL was truncated
+func isUnterminatedComment(sql string, i int) bool {
+	return strings.HasPrefix(sql[i:], "/*") && !strings.Contains(sql[i+2:], "*/")
+}
+
+// reservedWordsByFirstByte has the reservedWords that start with each byte, in the same order, so that peeking only
+// tries those that may be at the current position
+var reservedWordsByFirstByte = groupByFirstByte(reservedWords)
+
+func groupByFirstByte(rWords []string) map[byte][]string {
+	byFirstByte := map[byte][]string{}
+	for _, rWord := range rWords {
+		byFirstByte[rWord[0]] = append(byFirstByte[rWord[0]], rWord)
 	}
+	return byFirstByte
 }
 
+// RegisterReservedWords adds reserved words to the default ones, e.g. the keywords of a dialect, so that they're
+// lexed as keywords and can't be used as identifiers unless quoted. Like the default ones, they're matched
+// case-insensitively, and the words of a multi-word one (e.g. "QUALIFY BY") may be separated by any whitespace.
+//
+// The reserved words are global, so RegisterReservedWords must not be called while anything is being parsed. It's
+// meant to be called once on initialization, e.g. from an init function.
+func RegisterReservedWords(words ...string) {
+	for _, word := range words {
+		rWord := strings.ToUpper(strings.Join(strings.Fields(word), " "))
+		if rWord == "" || isReservedWord(rWord) {
+			continue
+		}
+		// It goes before any reserved word that's a prefix of it, so that it's peeked as a whole
+		i := len(reservedWords)
+		for j, existing := range reservedWords {
+			if strings.HasPrefix(rWord, existing) {
+				i = j
+				break
+			}
+		}
+		reservedWords = append(reservedWords[:i:i], append([]string{rWord}, reservedWords[i:]...)...)
+	}
+	reservedWordsByFirstByte = groupByFirstByte(reservedWords)
+}
+
+// upper returns the upper case version of an ASCII letter, or b as is if it's not a lower case one
+func upper(b byte) byte {
+	if b >= 'a' && b <= 'z' {
+		return b - 'a' + 'A'
+	}
+	return b
+}
+
+// reservedWords are peeked in order, so a reserved word must come before any other that is a prefix of it, e.g. ">="
+// before ">", so that it's peeked as a whole
 var reservedWords = []string{
-	"(", ")", ">=", "<=", "!=", ",", "=", ">", "<", "SELECT", "INSERT INTO", "VALUES", "UPDATE", "DELETE FROM",
-	"WHERE", "FROM", "SET", "AS",
+	"(", ")", ">=", "<=", "!=", "<>", ",", "=", ">", "<", "SELECT", "INSERT INTO", "REPLACE INTO", "VALUES", "UPDATE", "DELETE FROM",
+	"WHERE", "FROM", "SET", "AS", "AND", "OR", "IN", "NOT", "BETWEEN", "LIKE", "IS", "NULL", "GROUP BY", "HAVING", "ORDER BY",
+	"ASC", "DESCRIBE", "DESC", "NULLS FIRST", "NULLS LAST", "NULLS", "LIMIT", "OFFSET", "DISTINCT", "TOP", "INNER JOIN", "JOIN", "ON DUPLICATE KEY UPDATE", "ON",
+	"LEFT JOIN", "LEFT OUTER JOIN", "RIGHT JOIN", "RIGHT OUTER JOIN", "FULL JOIN", "FULL OUTER JOIN",
+	"CREATE TABLE", "PRIMARY KEY", "DEFAULT", "DROP TABLE", "IF EXISTS",
+	"TRUNCATE TABLE", "TRUNCATE", "UNION ALL", "UNION", "RETURNING", "CALL", "EXPLAIN ANALYZE", "EXPLAIN", "USING",
+	"COLLATE", "ESCAPE", "SHOW", "TABLES", "COLUMNS", "EXISTS",
+}
+
+// showKindKeywords are the keywords of each kind of SHOW query, e.g. for its error messages
+var showKindKeywords = map[query.ShowKind]string{
+	query.ShowTables:  "SHOW TABLES",
+	query.ShowColumns: "SHOW COLUMNS",
 }
 
 func (p *parser) peekWithLength() (string, int) {
+	token, ln := p.peekTokenWithLength()
+	return token.Value, ln
+}
+
+// peekTokenWithLength peeks the next token, whose Value is what peekWithLength peeks. The last peeked token is
+// remembered, so that peeking it again, e.g. to pop it, doesn't scan it again. Tokens aren't scanned ahead, though:
+// what a token is may depend on the step it's parsed in, so the parser peeks each one when it gets to it.
+func (p *parser) peekTokenWithLength() (Token, int) {
+	if p.peekedLn > 0 && p.peeked.Pos == p.i {
+		return p.peeked, p.peekedLn
+	}
+	token, ln := p.scanToken()
+	p.peeked, p.peekedLn = token, ln
+	return token, ln
+}
+
+// scanToken scans the token at the current position
+func (p *parser) scanToken() (Token, int) {
 	if p.i >= len(p.sql) {
-		return "", 0
+		return Token{Pos: p.i}, 0
 	}
-	for _, rWord := range reservedWords {
-		token := strings.ToUpper(p.sql[p.i:min(len(p.sql), p.i+len(rWord))])
-		if token == rWord {
-			return token, len(token)
+	for _, rWord := range reservedWordsByFirstByte[upper(p.sql[p.i])] {
+		if ln := p.reservedWordLength(rWord); ln > 0 && !p.continuesWord(rWord, ln) {
+			if !isWordByte(rWord[0]) {
+				return p.token(SymbolToken, rWord, ln), ln
+			}
+			return p.token(KeywordToken, rWord, ln), ln
 		}
 	}
 	if p.sql[p.i] == '\'' { // Quoted string
-		return p.peekQuotedStringWithLength()
+		quotedString, ln := p.peekQuotedStringWithLength()
+		return p.token(StringToken, quotedString, ln), ln
+	}
+	if placeholder, ln := p.peekPlaceholderWithLength(); ln > 0 {
+		return p.token(PlaceholderToken, placeholder, ln), ln
+	}
+	if number, ln := p.peekNumberWithLength(); ln > 0 {
+		return p.token(NumberToken, number, ln), ln
+	}
+	if identifier, ln := p.peekIdentifierWithLength(); ln > 0 {
+		return p.token(IdentifierToken, identifier, ln), ln
 	}
-	return p.peekIdentifierWithLength()
+	// The semicolon and arithmetic operators, which the parser handles by themselves rather than as reserved words
+	if strings.IndexByte(";+-*/", p.sql[p.i]) != -1 {
+		return p.token(SymbolToken, p.sql[p.i:p.i+1], 1), 1
+	}
+	return p.token(IdentifierToken, "", 0), 0
+}
+
+// token returns the token of the given kind and value that is ln bytes long at the current position
+func (p *parser) token(kind TokenKind, value string, ln int) Token {
+	return Token{Kind: kind, Text: p.sql[p.i : p.i+ln], Value: value, Pos: p.i}
 }
 
+// peekValueWithLength peeks a literal value, i.e. either a quoted string or a number, and its type
+func (p *parser) peekValueWithLength() (string, query.OperandType, int) {
+	if quotedValue, ln := p.peekQuotedStringWithLength(); ln > 0 {
+		return quotedValue, query.OpQuoted, ln
+	}
+	if number, ln := p.peekNumberWithLength(); ln > 0 {
+		return number, query.OpNumber, ln
+	}
+	return "", query.UnknownOperandType, 0
+}
+
+// peekPlaceholderWithLength peeks a positional parameter placeholder, i.e. either ? or $N where N starts at 1
+func (p *parser) peekPlaceholderWithLength() (string, int) {
+	if p.i >= len(p.sql) || (p.sql[p.i] != '?' && p.sql[p.i] != '$') {
+		return "", 0
+	}
+	i := p.i + 1
+	if p.sql[p.i] == '$' {
+		if i >= len(p.sql) || p.sql[i] < '1' || p.sql[i] > '9' {
+			return "", 0
+		}
+		for ; i < len(p.sql) && isDigit(p.sql[i]); i++ {
+		}
+	}
+	if i < len(p.sql) && isWordByte(p.sql[i]) {
+		return "", 0
+	}
+	return p.sql[p.i:i], i - p.i
+}
+
+// addParam records a placeholder in Params. A $N placeholder has index N, while ? ones are numbered in order.
+func (p *parser) addParam(placeholder string) {
+	index := 1
+	if placeholder == "?" {
+		for _, param := range p.query.Params {
+			if param.Placeholder == "?" {
+				index++
+			}
+		}
+	} else {
+		index, _ = strconv.Atoi(placeholder[1:])
+	}
+	p.query.Params = append(p.query.Params, query.Param{Index: index, Placeholder: placeholder})
+}
+
+// reservedWordLength returns the length of the reserved word at the current position, matched case-insensitively,
+// or 0 if it's not there. The words of a multi-word reserved word (e.g. GROUP BY) may be separated by any whitespace.
+func (p *parser) reservedWordLength(rWord string) int {
+	i := p.i
+	for j := 0; j < len(rWord); j++ {
+		if rWord[j] == ' ' {
+			if i >= len(p.sql) || !isWhitespace(p.sql[i]) {
+				return 0
+			}
+			for i < len(p.sql) && isWhitespace(p.sql[i]) {
+				i++
+			}
+			continue
+		}
+		if i >= len(p.sql) || upper(p.sql[i]) != rWord[j] {
+			return 0
+		}
+		i++
+	}
+	return i - p.i
+}
+
+// continuesWord reports whether the reserved word of length ln at the current position is just the prefix of a
+// longer identifier, e.g. "OR" in "origin" or "AS" in "asset".
+func (p *parser) continuesWord(rWord string, ln int) bool {
+	end := p.i + ln
+	return isWordByte(rWord[len(rWord)-1]) && end < len(p.sql) && isWordByte(p.sql[end])
+}
+
+// peekQuotedStringWithLength peeks a quoted string. A quote may be escaped either with a backslash, which is kept
+// as is, or by doubling it, which is unescaped into a single quote. A backslash escapes whatever byte follows it, so
+// e.g. 'a\\' is a string ending in an escaped backslash rather than an unclosed one.
 func (p *parser) peekQuotedStringWithLength() (string, int) {
-	if len(p.sql) < p.i || p.sql[p.i] != '\'' {
+	if len(p.sql) <= p.i || p.sql[p.i] != '\'' {
 		return "", 0
 	}
+	hasDoubledQuotes := false
 	for i := p.i + 1; i < len(p.sql); i++ {
-		if p.sql[i] == '\'' && p.sql[i-1] != '\\' {
-			return p.sql[p.i+1 : i], len(p.sql[p.i+1:i]) + 2 // +2 for the two quotes
+		if p.sql[i] == '\\' {
+			i++
+			continue
+		}
+		if p.sql[i] != '\'' {
+			continue
 		}
+		if i+1 < len(p.sql) && p.sql[i+1] == '\'' {
+			hasDoubledQuotes = true
+			i++
+			continue
+		}
+		value := p.sql[p.i+1 : i]
+		if hasDoubledQuotes {
+			value = strings.ReplaceAll(value, "''", "'")
+		}
+		return value, len(p.sql[p.i+1:i]) + 2 // +2 for the two quotes
 	}
 	return "", 0
 }
 
+// peekEscapeCharacterWithLength peeks the quoted string after ESCAPE, unescaped, e.g. \ for either '\' or '\\'. The
+// former is taken literally, as databases do, rather than as a quoted string that starts with an escaped quote.
+func (p *parser) peekEscapeCharacterWithLength() (string, int) {
+	if ln := quotedBackslashLength(p.sql, p.i); ln > 0 {
+		return `\`, ln
+	}
+	escape, ln := p.peekQuotedStringWithLength()
+	if len(escape) == 2 && escape[0] == '\\' {
+		escape = escape[1:]
+	}
+	return escape, ln
+}
+
+// quotedBackslashLength returns the length of the quoted backslash '\' at i, or 0 if there's none. It's not one if
+// another quote follows, which makes it a quoted string that starts with an escaped quote instead.
+func quotedBackslashLength(sql string, i int) int {
+	if strings.HasPrefix(sql[i:], `'\'`) && (i+3 == len(sql) || sql[i+3] != '\'') {
+		return 3
+	}
+	return 0
+}
+
+// isAfterEscape reports whether the ESCAPE keyword comes right before i, besides whitespace
+func isAfterEscape(sql string, i int) bool {
+	before := strings.TrimRight(sql[:i], " \t\n\r\v")
+	start := len(before) - len("ESCAPE")
+	return start >= 0 && strings.EqualFold(before[start:], "ESCAPE") && (start == 0 || !isWordByte(before[start-1]))
+}
+
+// popTableName pops a table name, which is either quoted or a plain identifier that's not a reserved word,
+// optionally qualified with a schema (e.g. public.users). Unlike other identifiers, it's never a function call,
+// so that e.g. "INSERT INTO a(b)" works.
+func (p *parser) popTableName(rWord string) (string, string, error) {
+	if quotedTableName, ln := p.peekQuotedStringWithLength(); ln > 0 {
+		p.popLength(ln)
+		return "", quotedTableName, nil
+	}
+	i := p.i
+	for i < len(p.sql) && (isWordByte(p.sql[i]) || p.sql[i] == '.' || p.isIdentifierQuote(p.sql[i]) || isInnerHyphen(p.sql, p.i, i)) {
+		if p.isIdentifierQuote(p.sql[i]) {
+			closingQuote := strings.IndexByte(p.sql[i+1:], p.sql[i])
+			if closingQuote == -1 {
+				break
+			}
+			i += closingQuote + 1
+		}
+		i++
+	}
+	parts := strings.Split(p.sql[p.i:i], ".")
+	for j, part := range parts {
+		if !isIdentifier(part) {
+			return "", "", fmt.Errorf("at %v: expected table name", rWord)
+		}
+		if !isQuotedIdentifier(part) && p.options.RejectUnquotedTableNames {
+			return "", "", fmt.Errorf("at %v: expected quoted table name", rWord)
+		}
+		parts[j] = unquoteIdentifier(part)
+	}
+	if len(parts) > 2 {
+		return "", "", fmt.Errorf("at %v: expected table name to have at most two parts, i.e. schema.table", rWord)
+	}
+	p.popLength(i - p.i)
+	if len(parts) == 2 {
+		return parts[0], parts[1], nil
+	}
+	return "", parts[0], nil
+}
+
+// popTableAlias pops the optional alias that follows a table name, either after AS or on its own
+func (p *parser) popTableAlias(rWord string) (string, error) {
+	maybeAlias := p.peek()
+	if maybeAlias == "AS" {
+		p.pop()
+		maybeAlias = p.peek()
+		if !isIdentifier(maybeAlias) {
+			return "", fmt.Errorf("at %v: expected table alias after AS", rWord)
+		}
+	}
+	if !isIdentifier(maybeAlias) {
+		return "", nil
+	}
+	p.pop()
+	return maybeAlias, nil
+}
+
+// peekValueOrNullWithLength peeks a literal value like peekValueWithLength, or the NULL literal, whose value is empty
+func (p *parser) peekValueOrNullWithLength() (string, query.OperandType, int) {
+	if value, valueType, ln := p.peekValueWithLength(); ln > 0 {
+		return value, valueType, ln
+	}
+	if p.peek() == "NULL" {
+		return "", query.OpNull, len("NULL")
+	}
+	return "", query.UnknownOperandType, 0
+}
+
+// peekNumberWithLength peeks a numeric literal, as recognized by isNumber
+func (p *parser) peekNumberWithLength() (string, int) {
+	i := p.i
+	if i < len(p.sql) && (p.sql[i] == '-' || p.sql[i] == '+') {
+		i++
+	}
+	for ; i < len(p.sql) && (isDigit(p.sql[i]) || p.sql[i] == '.'); i++ {
+	}
+	if i < len(p.sql) && (p.sql[i] == 'e' || p.sql[i] == 'E') { // Exponent, e.g. 1.5e10 or 2E-3
+		i++
+		if i < len(p.sql) && (p.sql[i] == '-' || p.sql[i] == '+') {
+			i++
+		}
+		for ; i < len(p.sql) && isDigit(p.sql[i]); i++ {
+		}
+	}
+	if i < len(p.sql) && isWordByte(p.sql[i]) || !isNumber(p.sql[p.i:i]) {
+		return "", 0
+	}
+	return p.sql[p.i:i], len(p.sql[p.i:i])
+}
+
+// isNumber reports whether s is a numeric literal, i.e. an optionally signed integer or decimal, e.g. 42, -3.14, .5
+// or 1., but not 1.2.3 or --1, optionally followed by an exponent, e.g. 1.5e10 or 2E-3, but not 1e
+func isNumber(s string) bool {
+	if len(s) > 0 && (s[0] == '-' || s[0] == '+') {
+		s = s[1:]
+	}
+	if exponent := strings.IndexAny(s, "eE"); exponent != -1 {
+		digits := s[exponent+1:]
+		if len(digits) > 0 && (digits[0] == '-' || digits[0] == '+') {
+			digits = digits[1:]
+		}
+		if digits == "" || strings.Trim(digits, "0123456789") != "" {
+			return false
+		}
+		s = s[:exponent]
+	}
+	if strings.Count(s, ".") > 1 || s == "" || s == "." {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) && s[i] != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *parser) peekNonNegativeInteger() (int, bool) {
+	number, ln := p.peekNumberWithLength()
+	if ln == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(number)
+	if err != nil || n < 0 || strings.HasPrefix(number, "-") {
+		return 0, false
+	}
+	return n, true
+}
+
+// peekIdentifierWithLength peeks a field or table name. A hyphen is part of it only when it's surrounded by word
+// characters, e.g. a-b, so a leading one is never part of an identifier; in a value position, -5 is a negative number.
 func (p *parser) peekIdentifierWithLength() (string, int) {
 	for i := p.i; i < len(p.sql); i++ {
-		if matched, _ := regexp.MatchString(`[a-zA-Z0-9_*]`, string(p.sql[i])); !matched {
+		if closingQuote := strings.IndexByte(p.sql[i+1:], p.sql[i]); p.isIdentifierQuote(p.sql[i]) && closingQuote != -1 {
+			i += closingQuote + 1 // Quoted identifier, e.g. "order", which is kept quoted
+			continue
+		}
+		if isInnerHyphen(p.sql, p.i, i) {
+			continue
+		}
+		if !isWordByte(p.sql[i]) && p.sql[i] != '*' && p.sql[i] != '.' {
+			if p.sql[i] == '(' && i > p.i { // Function call, e.g. count(id) or round(avg(x), 2)
+				if closingParens := closingParensIndex(p.sql, i); closingParens != -1 {
+					i = closingParens + 1
+				}
+			}
 			return p.sql[p.i:i], len(p.sql[p.i:i])
 		}
 	}
 	return p.sql[p.i:], len(p.sql[p.i:])
 }
 
+// parseFieldExpr parses the source text of a SELECTed field, e.g. substring(col, 0, 8), into its structured form
+func parseFieldExpr(text string) query.FieldExpr {
+	expr := query.FieldExpr{Text: text}
+	if value, valueType, ln := (&parser{sql: text}).peekValueOrNullWithLength(); ln == len(text) {
+		expr.Type = query.Literal
+		expr.Value = value
+		expr.ValueType = valueType
+		return expr
+	}
+	if openingParens := strings.IndexByte(text, '('); openingParens > 0 && closingParensIndex(text, openingParens) == len(text)-1 {
+		expr.Type = query.FunctionCall
+		expr.Name = text[:openingParens]
+		for _, arg := range splitArgs(text[openingParens+1 : len(text)-1]) {
+			expr.Args = append(expr.Args, parseFieldExpr(arg))
+		}
+		return expr
+	}
+	parts := strings.Split(text, ".")
+	for i, part := range parts {
+		if !columnNameRegexp.MatchString(part) && !isQuotedIdentifier(part) && (part != "*" || i != len(parts)-1) {
+			if hasArithmetic(text) {
+				expr.Type = query.Arithmetic
+			}
+			return expr
+		}
+	}
+	expr.Type = query.Column
+	if len(parts) > 1 {
+		expr.Type = query.QualifiedColumn
+		expr.Table = strings.Join(parts[:len(parts)-1], ".")
+	}
+	expr.Name = parts[len(parts)-1]
+	return expr
+}
+
+// columnNameRegexp matches column names, which may be hyphenated, e.g. my-col
+var columnNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z_0-9]*(-[a-zA-Z_0-9]+)*$`)
+
+var malformedExponentRegexp = regexp.MustCompile(`^[+-]?[0-9.]+[eE][+-]?$`)
+
+// hasArithmetic reports whether text has an arithmetic operator that's neither within quoted strings nor within
+// function calls, e.g. price * quantity, but not round(a / b)
+func hasArithmetic(text string) bool {
+	depth := 0
+	for i := 0; i < len(text); i++ {
+		switch text[i] {
+		case '\'':
+			i = closingQuoteIndex(text, i)
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '+', '-', '*', '/':
+			if depth == 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// splitArgs splits the arguments of a function call on the commas that are neither within quoted strings nor within
+// nested calls, trimming them
+func splitArgs(args string) []string {
+	if strings.TrimSpace(args) == "" {
+		return nil
+	}
+	split := []string{}
+	start, depth := 0, 0
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case '\'':
+			i = closingQuoteIndex(args, i)
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				split = append(split, strings.TrimSpace(args[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(split, strings.TrimSpace(args[start:]))
+}
+
+// closingQuoteIndex returns the index of the quote that closes the one at openingQuote, or len(s) if it's unclosed.
+// Like in peekQuotedStringWithLength, a backslash escapes whatever byte follows it.
+func closingQuoteIndex(s string, openingQuote int) int {
+	for i := openingQuote + 1; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+		} else if s[i] == '\'' {
+			return i
+		}
+	}
+	return len(s)
+}
+
+// closingParensIndex returns the index of the parens that closes the one at openingParens, or -1 if it's unclosed.
+// Parens within quoted strings, e.g. concat(a, ')'), don't count.
+func closingParensIndex(s string, openingParens int) int {
+	depth := 0
+	for i := openingParens; i < len(s); i++ {
+		switch s[i] {
+		case '\'':
+			i = closingQuoteIndex(s, i)
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
 func (p *parser) validate() error {
-	if len(p.query.Conditions) == 0 && p.step == stepWhereField {
-		return fmt.Errorf("at WHERE: empty WHERE clause")
+	if p.conditions != nil && len(*p.conditions) == 0 && p.step == stepConditionField {
+		return fmt.Errorf("at %s: empty %s clause", p.conditionsRWord, p.conditionsRWord)
+	}
+	if p.conditions != nil && p.step == stepConditionField && p.conditionNegated {
+		return fmt.Errorf("at %s: expected condition after NOT", p.conditionsRWord)
+	}
+	if p.conditions != nil && len(*p.conditions) > 0 && p.step == stepConditionField && p.isAfterOpeningParens() {
+		return fmt.Errorf("at %s: expected condition after opening parens", p.conditionsRWord)
+	}
+	if p.conditions != nil && len(*p.conditions) > 0 && p.step == stepConditionField {
+		return fmt.Errorf("at %s: expected condition after AND/OR", p.conditionsRWord)
+	}
+	if p.step == stepConditionInValues || p.step == stepConditionInValuesCommaOrClosingParens {
+		return fmt.Errorf("at %s: expected closing parens in IN list", p.conditionsRWord)
+	}
+	if p.step == stepConditionBetweenLowerBound || p.step == stepConditionBetweenAnd || p.step == stepConditionBetweenUpperBound {
+		return fmt.Errorf("at %s: expected BETWEEN lower AND upper bounds", p.conditionsRWord)
+	}
+	if p.step == stepConditionLikePattern {
+		return fmt.Errorf("at %s: expected quoted pattern after LIKE", p.conditionsRWord)
+	}
+	if p.step == stepTablesTable {
+		return fmt.Errorf("at %s: expected table name", p.tablesRWord)
+	}
+	if p.step == stepReturningField {
+		return fmt.Errorf("at RETURNING: expected field to return")
+	}
+	if p.step == stepCreateTableOpeningParens {
+		return fmt.Errorf("at CREATE TABLE: expected opening parens")
+	}
+	if p.step == stepCreateTableColumn || p.step == stepCreateTableColumnType || p.step == stepCreateTableColumnModifierCommaOrClosingParens {
+		return fmt.Errorf("at CREATE TABLE: expected closing parens")
+	}
+	if p.step == stepDropTableName {
+		return fmt.Errorf("at DROP TABLE: expected table name")
+	}
+	if p.step == stepTruncateTable {
+		return fmt.Errorf("at TRUNCATE: expected table name")
+	}
+	if p.step == stepDescribeTable {
+		return fmt.Errorf("at DESCRIBE: expected table name")
+	}
+	if p.step == stepShowKind {
+		return fmt.Errorf("at SHOW: expected TABLES or COLUMNS")
+	}
+	if p.step == stepShowColumnsFrom {
+		return fmt.Errorf("at SHOW COLUMNS: expected FROM")
+	}
+	if p.step == stepShowColumnsTable {
+		return fmt.Errorf("at SHOW COLUMNS: expected table name")
+	}
+	if p.step == stepCallProcName {
+		return fmt.Errorf("at CALL: expected procedure name")
+	}
+	if p.step == stepCallOpeningParens {
+		return fmt.Errorf("at CALL: expected opening parens")
+	}
+	if p.step == stepCallArg || p.step == stepCallCommaOrClosingParens {
+		return fmt.Errorf("at CALL: expected closing parens")
+	}
+	if p.step == stepJoinTable {
+		return fmt.Errorf("at JOIN: expected table name")
+	}
+	if p.step == stepJoinOn {
+		return fmt.Errorf("at JOIN: expected ON")
+	}
+	if p.step == stepLimitValue {
+		return fmt.Errorf("at LIMIT: expected non-negative integer")
+	}
+	if p.step == stepOffsetValue {
+		return fmt.Errorf("at OFFSET: expected non-negative integer")
+	}
+	if p.step == stepGroupByField {
+		return fmt.Errorf("at GROUP BY: expected field to GROUP BY")
+	}
+	if p.step == stepOrderByField {
+		return fmt.Errorf("at ORDER BY: expected field to ORDER BY")
+	}
+	if p.query.Type == query.UnknownType && p.query.Explain {
+		return fmt.Errorf("at EXPLAIN: expected query to explain")
 	}
 	if p.query.Type == query.UnknownType {
 		return fmt.Errorf("query type cannot be empty")
 	}
-	if p.query.TableName == "" {
+	if p.query.TableName == "" && p.query.Type != query.Call && p.query.ShowKind != query.ShowTables && p.query.FromSubquery == nil {
 		return fmt.Errorf("table name cannot be empty")
 	}
 	if len(p.query.Conditions) == 0 && (p.query.Type == query.Update || p.query.Type == query.Delete) {
 		return fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE")
 	}
-	for _, c := range p.query.Conditions {
-		if c.Operator == query.UnknownOperator {
-			return fmt.Errorf("at WHERE: condition without operator")
-		}
-		if c.Operand1 == "" && c.Operand1IsField {
-			return fmt.Errorf("at WHERE: condition with empty left side operand")
+	if p.query.Type == query.Update && len(p.query.Updates) == 0 {
+		return fmt.Errorf("at UPDATE: expected at least one assignment")
+	}
+	if err := validateConditions("WHERE", p.query.Conditions); err != nil {
+		return err
+	}
+	if err := validateConditions("HAVING", p.query.Having); err != nil {
+		return err
+	}
+	for _, j := range p.query.Joins {
+		if err := validateConditions("ON", j.On); err != nil {
+			return err
 		}
-		if c.Operand2 == "" && c.Operand2IsField {
-			return fmt.Errorf("at WHERE: condition with empty right side operand")
+	}
+	isInsert := p.query.Type == query.Insert || p.query.Type == query.Replace
+	if isInsert && p.query.OnDuplicate != nil {
+		switch p.step {
+		case stepUpdateField:
+			return fmt.Errorf("at ON DUPLICATE KEY UPDATE: expected at least one field to update")
+		case stepUpdateEquals:
+			return fmt.Errorf("at ON DUPLICATE KEY UPDATE: expected '='")
+		case stepUpdateValue:
+			return fmt.Errorf("at ON DUPLICATE KEY UPDATE: expected quoted value")
 		}
 	}
-	if p.query.Type == query.Insert && len(p.query.Inserts) == 0 {
-		return fmt.Errorf("at INSERT INTO: need at least one row to insert")
+	if isInsert && len(p.query.Inserts) == 0 && p.query.InsertSelect == nil {
+		return fmt.Errorf("at %s: need at least one row to insert", p.insertRWord())
 	}
-	if p.query.Type == query.Insert {
-		for _, i := range p.query.Inserts {
-			if len(i) != len(p.query.Fields) {
-				return fmt.Errorf("at INSERT INTO: value count doesn't match field count")
+	if isInsert && len(p.query.Inserts) > 0 && p.query.InsertSelect != nil {
+		return fmt.Errorf("at %s: expected either VALUES or SELECT, not both", p.insertRWord())
+	}
+	if p.query.Union != nil && len(p.query.Union.Query.Fields) != len(p.query.Fields) {
+		return fmt.Errorf("at UNION: expected both SELECTs to have the same number of fields")
+	}
+	if isInsert {
+		for i, row := range p.query.Inserts {
+			if len(row) != len(p.query.Fields) {
+				return p.valueCountError(i + 1)
 			}
 		}
 	}
 	return nil
 }
 
-func (p *parser) logError() {
-	if p.err == nil {
-		return
+// valueCountError is the error for an INSERTed row whose value count doesn't match the field count, given its
+// 1-based index
+func (p *parser) valueCountError(row int) error {
+	values, fields := len(p.query.Inserts[row-1]), len(p.query.Fields)
+	return fmt.Errorf("at %s: row %d has %d values but %d fields", p.insertRWord(), row, values, fields)
+}
+
+func validateConditions(rWord string, conditions []query.Condition) error {
+	for _, c := range conditions {
+		if c.Operator == query.UnknownOperator {
+			return fmt.Errorf("at %s: condition without operator", rWord)
+		}
+		if c.Operand1 == "" && c.Operand1IsField {
+			return fmt.Errorf("at %s: condition with empty left side operand", rWord)
+		}
+		if c.Operand2 == "" && c.Operand2IsField {
+			return fmt.Errorf("at %s: condition with empty right side operand", rWord)
+		}
+		if c.Operand2Type == query.UnknownOperandType && !c.Operator.IsUnary() && c.Subquery == nil {
+			return fmt.Errorf("at %s: condition without right side operand", rWord)
+		}
+		if c.Operand2Type == query.OpList && len(c.Operand2List) == 0 {
+			return fmt.Errorf("at %s: empty IN list", rWord)
+		}
 	}
-	fmt.Println(p.sql)
-	fmt.Println(strings.Repeat(" ", p.i) + "^")
-	fmt.Println(p.err)
+	return nil
 }
 
+// isIdentifier reports whether s may be a field or table name, which includes qualified names (e.g. users.id) and
+// function calls with any arguments (e.g. COUNT(*) or round(avg(x), 2)), but not reserved words nor anything
+// starting with a hyphen, which is a negative number instead
 func isIdentifier(s string) bool {
-	for _, rw := range reservedWords {
-		if strings.ToUpper(s) == rw {
-			return false
+	if isQuotedIdentifier(s) {
+		return true
+	}
+	if strings.HasPrefix(s, "-") {
+		return false
+	}
+	if isReservedWord(s) {
+		return false
+	}
+	if malformedExponentRegexp.MatchString(s) { // e.g. 1e, which is not a number because its exponent is missing
+		return false
+	}
+	return identifierRegexp.MatchString(s)
+}
+
+var identifierRegexp = regexp.MustCompile(`[a-zA-Z_][a-zA-Z_0-9]*`)
+
+// isIdentifierQuote reports whether b quotes identifiers, as per the IdentifierQuotes option
+func (p *parser) isIdentifierQuote(b byte) bool {
+	return strings.IndexByte(p.options.IdentifierQuotes, b) != -1
+}
+
+// isQuotedIdentifier reports whether s is quoted with double quotes or backticks, e.g. "order". Such identifiers are
+// only ever peeked if their quotes are in the IdentifierQuotes option.
+func isQuotedIdentifier(s string) bool {
+	return len(s) >= 2 && (s[0] == '"' || s[0] == '`') && s[len(s)-1] == s[0] && strings.IndexByte(s[1:len(s)-1], s[0]) == -1
+}
+
+func unquoteIdentifier(s string) string {
+	if isQuotedIdentifier(s) {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// isReservedWord reports whether s is one of the reservedWords, matched case-insensitively
+func isReservedWord(s string) bool {
+	for _, rWord := range reservedWords {
+		if strings.EqualFold(s, rWord) {
+			return true
 		}
 	}
-	matched, _ := regexp.MatchString("[a-zA-Z_][a-zA-Z_0-9]*", s)
-	return matched
+	return false
 }
 
 func isIdentifierOrAsterisk(s string) bool {
 	return isIdentifier(s) || s == "*"
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+func isWhitespace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v'
+}
+
+// isInnerHyphen reports whether sql[i] is a hyphen within the identifier that starts at start, e.g. the one in my-table
+func isInnerHyphen(sql string, start, i int) bool {
+	return sql[i] == '-' && i > start && isWordByte(sql[i-1]) && i+1 < len(sql) && isWordByte(sql[i+1])
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
 }
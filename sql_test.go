@@ -1,11 +1,19 @@
 package sqlparser
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 	"text/template"
 
 	"github.com/marianogappa/sqlparser/query"
@@ -24,6 +32,11 @@ type output struct {
 	ErrorExamples   []testCase
 	Types           []string
 	Operators       []string
+	OperandTypes    []string
+	Directions      []string
+	JoinTypes       []string
+	NullsOrders     []string
+	ShowKinds       []string
 }
 
 func TestSQL(t *testing.T) {
@@ -65,416 +78,4492 @@ func TestSQL(t *testing.T) {
 			Err:      nil,
 		},
 		{
-			Name:     "SELECT many fields works",
-			SQL:      "SELECT a, c, d FROM 'b'",
-			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a", "c", "d"}},
-			Err:      nil,
+			Name: "SELECT with IN works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x iN (1)",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.In, Operand2Type: query.OpList, Operand2List: []string{"1"}, Operand2ListTypes: []query.OperandType{query.OpNumber}}},
+			},
+			Err: nil,
 		},
 		{
-			Name: "SELECT with alias works",
-			SQL:  "SELECT a as z, b as y, c FROM 'b'",
+			Name: "SELECT with NOT IN works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x nOt In (1)",
 			Expected: query.Query{
-				Type:      query.Select,
-				TableName: "b",
-				Fields:    []string{"a", "b", "c"},
-				Aliases: map[string]string{
-					"a": "z",
-					"b": "y",
-				},
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.NotIn, Operand2Type: query.OpList, Operand2List: []string{"1"}, Operand2ListTypes: []query.OperandType{query.OpNumber}}},
 			},
 			Err: nil,
 		},
-
 		{
-			Name:     "SELECT with empty WHERE fails",
-			SQL:      "SELECT a, c, d FROM 'b' WHERE",
-			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a", "c", "d"}},
-			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+			Name: "SELECT with IS NULL works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x iS nUlL",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.IsNull}},
+			},
+			Err: nil,
 		},
 		{
-			Name:     "SELECT with WHERE with only operand fails",
-			SQL:      "SELECT a, c, d FROM 'b' WHERE a",
-			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a", "c", "d"}},
-			Err:      fmt.Errorf("at WHERE: condition without operator"),
+			Name: "SELECT with IS NOT NULL works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x is not null",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.IsNotNull}},
+			},
+			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with = works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a = ''",
+			Name: "SELECT with LIKE works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x lIkE 'y%'",
 			Expected: query.Query{
-				Type:      query.Select,
-				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "", Operand2IsField: false},
-				},
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.Like, Operand2: "y%", Operand2Type: query.OpQuoted}},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with < works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a < '1'",
+			Name: "SELECT with NOT LIKE works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x not like 'y%'",
 			Expected: query.Query{
-				Type:      query.Select,
-				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Lt, Operand2: "1", Operand2IsField: false},
-				},
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.NotLike, Operand2: "y%", Operand2Type: query.OpQuoted}},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with <= works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a <= '1'",
+			Name: "SELECT with BETWEEN works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x bEtWeEn 1 aNd 2",
 			Expected: query.Query{
-				Type:      query.Select,
-				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Lte, Operand2: "1", Operand2IsField: false},
-				},
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.Between, Operand2: "1", Operand2Type: query.OpNumber, Operand3: "2", Operand3Type: query.OpNumber}},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with > works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a > '1'",
+			Name: "SELECT with = NULL works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x = nUlL",
 			Expected: query.Query{
-				Type:      query.Select,
-				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Gt, Operand2: "1", Operand2IsField: false},
-				},
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "x", Operand1IsField: true, Operator: query.Eq, Operand2Type: query.OpNull}},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with >= works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a >= '1'",
+			Name: "SELECT with AND and OR works with lowercase",
+			SQL:  "select a fRoM 'b' wHeRe x = 1 aNd y = 2 oR z = 3",
 			Expected: query.Query{
 				Type:      query.Select,
 				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
+				Fields:    []string{"a"},
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Gte, Operand2: "1", Operand2IsField: false},
+					{Operand1: "x", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
+					{Operand1: "y", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpNumber, OrWithNext: true},
+					{Operand1: "z", Operand1IsField: true, Operator: query.Eq, Operand2: "3", Operand2Type: query.OpNumber},
 				},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with != works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1'",
+			Name:     "SELECT many fields works",
+			SQL:      "SELECT a, c, d FROM 'b'",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a", "c", "d"}},
+			Err:      nil,
+		},
+		{
+			Name: "SELECT with alias works",
+			SQL:  "SELECT a as z, b as y, c FROM 'b'",
 			Expected: query.Query{
 				Type:      query.Select,
 				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Ne, Operand2: "1", Operand2IsField: false},
+				Fields:    []string{"a", "b", "c"},
+				Aliases: map[string]string{
+					"a": "z",
+					"b": "y",
 				},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with != works (comparing field against another field)",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a != b",
+			Name: "SELECT with alias without AS works",
+			SQL:  "SELECT a z, b AS y, count(c) total, d from 'b'",
 			Expected: query.Query{
 				Type:      query.Select,
 				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Ne, Operand2: "b", Operand2IsField: true},
+				Fields:    []string{"a", "b", "count(c)", "d"},
+				Aliases: map[string]string{
+					"a":        "z",
+					"b":        "y",
+					"count(c)": "total",
 				},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT * works",
-			SQL:  "SELECT * FROM 'b'",
+			Name:     "SELECT with duplicate alias fails",
+			SQL:      "SELECT a AS x, b, c x FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: duplicate alias x"),
+		},
+		{
+			Name:     "SELECT with AS but no alias fails",
+			SQL:      "SELECT a AS FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected field alias for \"a as\" to SELECT"),
+		},
+
+		{
+			Name: "SELECT with table alias works",
+			SQL:  "SELECT a, b, c FROM 'tab' t WHERE t = '1'",
 			Expected: query.Query{
 				Type:       query.Select,
-				TableName:  "b",
-				Fields:     []string{"*"},
-				Conditions: nil,
+				TableName:  "tab",
+				TableAlias: "t",
+				Fields:     []string{"a", "b", "c"},
+				Conditions: []query.Condition{
+					{Operand1: "t", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT a, * works",
-			SQL:  "SELECT a, * FROM 'b'",
+			Name: "SELECT with table alias using AS works",
+			SQL:  "SELECT a FROM 'tab' as t ORDER BY a",
 			Expected: query.Query{
 				Type:       query.Select,
-				TableName:  "b",
-				Fields:     []string{"a", "*"},
-				Conditions: nil,
+				TableName:  "tab",
+				TableAlias: "t",
+				Fields:     []string{"a"},
+				OrderBy:    []query.OrderByField{{Field: "a", Direction: query.Asc}},
 			},
 			Err: nil,
 		},
 		{
-			Name: "SELECT with WHERE with two conditions using AND works",
-			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1' AND b = '2'",
+			Name:     "SELECT with table alias with AS but no alias fails",
+			SQL:      "SELECT a FROM 'tab' AS WHERE a = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected table alias after AS"),
+		},
+		{
+			Name: "SELECT with unquoted table name works",
+			SQL:  "SELECT a FROM users u WHERE a = '1'",
 			Expected: query.Query{
-				Type:      query.Select,
-				TableName: "b",
-				Fields:    []string{"a", "c", "d"},
+				Type:       query.Select,
+				TableName:  "users",
+				TableAlias: "u",
+				Fields:     []string{"a"},
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Ne, Operand2: "1", Operand2IsField: false},
-					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false},
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
 				},
 			},
 			Err: nil,
 		},
 		{
-			Name:     "Empty UPDATE fails",
-			SQL:      "UPDATE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("table name cannot be empty"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name fails",
-			SQL:      "UPDATE 'a'",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name and SET fails",
-			SQL:      "UPDATE 'a' SET",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name, SET with a field but no value and WHERE fails",
-			SQL:      "UPDATE 'a' SET b WHERE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at UPDATE: expected '='"),
-		},
-		{
-			Name:     "Incomplete UPDATE with table name, SET with a field and = but no value and WHERE fails",
-			SQL:      "UPDATE 'a' SET b = WHERE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+			Name: "SELECT with schema-qualified table name and qualified fields works",
+			SQL:  "SELECT users.id, u.name FROM public.users u WHERE users.id = u.parent_id",
+			Expected: query.Query{
+				Type:       query.Select,
+				Schema:     "public",
+				TableName:  "users",
+				TableAlias: "u",
+				Fields:     []string{"users.id", "u.name"},
+				Conditions: []query.Condition{
+					{Operand1: "users.id", Operand1IsField: true, Operator: query.Eq, Operand2: "u.parent_id", Operand2IsField: true, Operand2Type: query.OpField},
+				},
+			},
+			Err: nil,
 		},
 		{
-			Name:     "Incomplete UPDATE due to no WHERE clause fails",
-			SQL:      "UPDATE 'a' SET b = 'hello' WHERE",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+			Name: "SELECT with INNER JOIN works",
+			SQL:  "SELECT a FROM 'x' INNER JOIN 'y' ON x.id = y.x_id WHERE a = '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "x",
+				Joins: []query.Join{
+					{
+						Type:      query.Inner,
+						TableName: "y",
+						On: []query.Condition{
+							{Operand1: "x.id", Operand1IsField: true, Operator: query.Eq, Operand2: "y.x_id", Operand2IsField: true, Operand2Type: query.OpField},
+						},
+					},
+				},
+				Fields: []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
 		},
 		{
-			Name:     "Incomplete UPDATE due incomplete WHERE clause fails",
-			SQL:      "UPDATE 'a' SET b = 'hello' WHERE a",
-			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: condition without operator"),
+			Name: "SELECT with plain JOIN and aliases works",
+			SQL:  "SELECT u.name FROM users u JOIN public.orders AS o ON u.id = o.user_id",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "users",
+				TableAlias: "u",
+				Joins: []query.Join{
+					{
+						Type:       query.Inner,
+						Schema:     "public",
+						TableName:  "orders",
+						TableAlias: "o",
+						On: []query.Condition{
+							{Operand1: "u.id", Operand1IsField: true, Operator: query.Eq, Operand2: "o.user_id", Operand2IsField: true, Operand2Type: query.OpField},
+						},
+					},
+				},
+				Fields: []string{"u.name"},
+			},
+			Err: nil,
 		},
 		{
-			Name: "UPDATE works",
-			SQL:  "UPDATE 'a' SET b = 'hello' WHERE a = '1'",
+			Name: "SELECT with two joins in sequence works",
+			SQL:  "SELECT a FROM 'x' JOIN 'y' ON x.id = y.x_id RIGHT OUTER JOIN 'z' ON y.id = z.y_id ORDER BY a",
 			Expected: query.Query{
-				Type:      query.Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false},
+				Type:      query.Select,
+				TableName: "x",
+				Joins: []query.Join{
+					{
+						Type:      query.Inner,
+						TableName: "y",
+						On: []query.Condition{
+							{Operand1: "x.id", Operand1IsField: true, Operator: query.Eq, Operand2: "y.x_id", Operand2IsField: true, Operand2Type: query.OpField},
+						},
+					},
+					{
+						Type:      query.Right,
+						TableName: "z",
+						On: []query.Condition{
+							{Operand1: "y.id", Operand1IsField: true, Operator: query.Eq, Operand2: "z.y_id", Operand2IsField: true, Operand2Type: query.OpField},
+						},
+					},
 				},
+				Fields:  []string{"a"},
+				OrderBy: []query.OrderByField{{Field: "a", Direction: query.Asc}},
 			},
 			Err: nil,
 		},
 		{
-			Name: "UPDATE works with simple quote inside",
-			SQL:  "UPDATE 'a' SET b = 'hello\\'world' WHERE a = '1'",
+			Name: "SELECT with LEFT JOIN with table alias works",
+			SQL:  "SELECT a FROM 'x' left outer join 'y' AS b ON x.id = b.x_id",
 			Expected: query.Query{
-				Type:      query.Update,
-				TableName: "a",
-				Updates:   map[string]string{"b": "hello\\'world"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false},
+				Type:      query.Select,
+				TableName: "x",
+				Joins: []query.Join{
+					{
+						Type:       query.Left,
+						TableName:  "y",
+						TableAlias: "b",
+						On: []query.Condition{
+							{Operand1: "x.id", Operand1IsField: true, Operator: query.Eq, Operand2: "b.x_id", Operand2IsField: true, Operand2Type: query.OpField},
+						},
+					},
 				},
+				Fields: []string{"a"},
 			},
 			Err: nil,
 		},
 		{
-			Name: "UPDATE with multiple SETs works",
-			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1'",
+			Name: "SELECT with multi-condition ON followed by WHERE and GROUP BY works",
+			SQL:  "SELECT a.x FROM 'a' JOIN 'b' ON a.x = b.x AND a.y = b.y OR a.z IS NULL WHERE a.x > 1 GROUP BY a.x",
 			Expected: query.Query{
-				Type:      query.Update,
+				Type:      query.Select,
 				TableName: "a",
-				Updates:   map[string]string{"b": "hello", "c": "bye"},
+				Joins: []query.Join{
+					{
+						Type:      query.Inner,
+						TableName: "b",
+						On: []query.Condition{
+							{Operand1: "a.x", Operand1IsField: true, Operator: query.Eq, Operand2: "b.x", Operand2IsField: true, Operand2Type: query.OpField},
+							{Operand1: "a.y", Operand1IsField: true, Operator: query.Eq, Operand2: "b.y", Operand2IsField: true, Operand2Type: query.OpField, OrWithNext: true},
+							{Operand1: "a.z", Operand1IsField: true, Operator: query.IsNull},
+						},
+					},
+				},
+				Fields: []string{"a.x"},
 				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false},
+					{Operand1: "a.x", Operand1IsField: true, Operator: query.Gt, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpNumber},
 				},
+				GroupBy: []string{"a.x"},
 			},
 			Err: nil,
 		},
 		{
-			Name: "UPDATE with multiple SETs and multiple conditions works",
-			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1' AND b = '789'",
+			Name: "SELECT with multi-condition ON followed by another JOIN works",
+			SQL:  "SELECT a.x FROM 'a' JOIN 'b' ON a.x = b.x AND b.y IN ('1', '2') LEFT JOIN 'c' ON b.x = c.x",
 			Expected: query.Query{
-				Type:      query.Update,
+				Type:      query.Select,
 				TableName: "a",
-				Updates:   map[string]string{"b": "hello", "c": "bye"},
-				Conditions: []query.Condition{
-					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false},
-					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "789", Operand2IsField: false},
+				Joins: []query.Join{
+					{
+						Type:      query.Inner,
+						TableName: "b",
+						On: []query.Condition{
+							{Operand1: "a.x", Operand1IsField: true, Operator: query.Eq, Operand2: "b.x", Operand2IsField: true, Operand2Type: query.OpField},
+							{Operand1: "b.y", Operand1IsField: true, Operator: query.In, Operand2Type: query.OpList, Operand2List: []string{"1", "2"}, Operand2ListTypes: []query.OperandType{query.OpQuoted, query.OpQuoted}},
+						},
+					},
+					{
+						Type:      query.Left,
+						TableName: "c",
+						On: []query.Condition{
+							{Operand1: "b.x", Operand1IsField: true, Operator: query.Eq, Operand2: "c.x", Operand2IsField: true, Operand2Type: query.OpField},
+						},
+					},
 				},
+				Fields: []string{"a.x"},
 			},
 			Err: nil,
 		},
 		{
-			Name:     "Empty DELETE fails",
-			SQL:      "DELETE FROM",
+			Name:     "SELECT with dangling AND in ON fails",
+			SQL:      "SELECT a FROM 'x' JOIN 'y' ON x.id = y.x_id AND",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("table name cannot be empty"),
+			Err:      fmt.Errorf("at ON: expected condition after AND/OR"),
 		},
 		{
-			Name:     "DELETE without WHERE fails",
-			SQL:      "DELETE FROM 'a'",
+			Name:     "SELECT with JOIN without ON fails",
+			SQL:      "SELECT a FROM 'x' JOIN 'y' WHERE a = '1'",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+			Err:      fmt.Errorf("at JOIN: expected ON"),
 		},
 		{
-			Name:     "DELETE with empty WHERE fails",
-			SQL:      "DELETE FROM 'a' WHERE",
+			Name:     "SELECT with JOIN without ON at the end fails",
+			SQL:      "SELECT a FROM 'x' JOIN 'y'",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+			Err:      fmt.Errorf("at JOIN: expected ON"),
 		},
 		{
-			Name:     "DELETE with WHERE with field but no operator fails",
-			SQL:      "DELETE FROM 'a' WHERE b",
+			Name:     "SELECT with JOIN without table fails",
+			SQL:      "SELECT a FROM 'x' JOIN ON x.id = y.x_id",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at WHERE: condition without operator"),
+			Err:      fmt.Errorf("at JOIN: expected table name"),
 		},
 		{
-			Name: "DELETE with WHERE works",
-			SQL:  "DELETE FROM 'a' WHERE b = '1'",
+			Name:     "SELECT with JOIN with empty ON fails",
+			SQL:      "SELECT a FROM 'x' JOIN 'y' ON",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ON: empty ON clause"),
+		},
+		{
+			Name: "SELECT with fully qualified field in WHERE works",
+			SQL:  "SELECT a FROM public.users u WHERE public.users.id = u.parent_id",
 			Expected: query.Query{
-				Type:      query.Delete,
-				TableName: "a",
+				Type:       query.Select,
+				Schema:     "public",
+				TableName:  "users",
+				TableAlias: "u",
+				Fields:     []string{"a"},
 				Conditions: []query.Condition{
-					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false},
+					{Operand1: "public.users.id", Operand1IsField: true, Operator: query.Eq, Operand2: "u.parent_id", Operand2IsField: true, Operand2Type: query.OpField},
 				},
 			},
 			Err: nil,
 		},
 		{
-			Name:     "Empty INSERT fails",
-			SQL:      "INSERT INTO",
+			Name:     "SELECT with four-part field in WHERE fails",
+			SQL:      "SELECT a FROM users WHERE db.public.users.id = '1'",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("table name cannot be empty"),
+			Err:      fmt.Errorf("at WHERE: expected field to have at most three parts, i.e. schema.table.column"),
 		},
 		{
-			Name:     "INSERT with no rows to insert fails",
-			SQL:      "INSERT INTO 'a'",
+			Name:     "SELECT with empty part in field in WHERE fails",
+			SQL:      "SELECT a FROM users WHERE users..id = '1'",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Err:      fmt.Errorf("at WHERE: empty part in field users..id"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails",
-			SQL:      "INSERT INTO 'a' (",
+			Name:     "SELECT with field qualified with unknown alias fails",
+			SQL:      "SELECT a FROM users u WHERE u.id = v.id",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Err:      fmt.Errorf("at WHERE: unknown table or alias v in field v.id"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails #2",
-			SQL:      "INSERT INTO 'a' (b",
+			Name:     "SELECT with ON field qualified with unknown table fails",
+			SQL:      "SELECT a FROM x JOIN y ON z.id = y.x_id",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Err:      fmt.Errorf("at ON: unknown table or alias z in field z.id"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails #3",
-			SQL:      "INSERT INTO 'a' (b)",
+			Name: "DELETE with field qualified with any table works when there are no aliases",
+			SQL:  "DELETE FROM users WHERE other.id = '1'",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "users",
+				Conditions: []query.Condition{
+					{Operand1: "other.id", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with three-part table name fails",
+			SQL:      "SELECT a FROM db.public.users",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Err:      fmt.Errorf("at SELECT: expected table name to have at most two parts, i.e. schema.table"),
 		},
 		{
-			Name:     "INSERT with incomplete value section fails #4",
-			SQL:      "INSERT INTO 'a' (b) VALUES",
+			Name:     "SELECT with empty schema fails",
+			SQL:      "SELECT a FROM .users",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+			Err:      fmt.Errorf("at SELECT: expected table name"),
 		},
 		{
-			Name:     "INSERT with incomplete row fails",
-			SQL:      "INSERT INTO 'a' (b) VALUES (",
+			Name:     "SELECT with reserved word as table name fails",
+			SQL:      "SELECT a FROM WHERE a = '1'",
 			Expected: query.Query{},
-			Err:      fmt.Errorf("at INSERT INTO: value count doesn't match field count"),
+			Err:      fmt.Errorf("at SELECT: expected table name"),
 		},
 		{
-			Name: "INSERT works",
-			SQL:  "INSERT INTO 'a' (b) VALUES ('1')",
+			Name: "SELECT DISTINCT works",
+			SQL:  "select distinct vopenid, days from test",
 			Expected: query.Query{
-				Type:      query.Insert,
-				TableName: "a",
-				Fields:    []string{"b"},
-				Inserts:   [][]string{{"1"}},
+				Type:      query.Select,
+				TableName: "test",
+				Fields:    []string{"vopenid", "days"},
+				Distinct:  true,
 			},
 			Err: nil,
 		},
 		{
-			Name:     "INSERT * fails",
-			SQL:      "INSERT INTO 'a' (*) VALUES ('1')",
-			Expected: query.Query{},
+			Name: "SELECT DISTINCT * works",
+			SQL:  "SELECT DISTINCT * FROM 'b'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"*"},
+				Distinct:  true,
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with function call with many arguments and alias works",
+			SQL:  "select distinct vopenid, substring(tdbank_imp_date_mov,0,8) as online_dt, days from test",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "test",
+				Fields:    []string{"vopenid", "substring(tdbank_imp_date_mov,0,8)", "days"},
+				Aliases:   map[string]string{"substring(tdbank_imp_date_mov,0,8)": "online_dt"},
+				Distinct:  true,
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with nested function calls works",
+			SQL:  "SELECT round(avg(x), 2) AS avg_x, concat(upper(a), lower(b)) FROM 'b'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"round(avg(x), 2)", "concat(upper(a), lower(b))"},
+				Aliases:   map[string]string{"round(avg(x), 2)": "avg_x"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with function calls with nested calls and many arguments works",
+			SQL:  "SELECT sum(coalesce(x,0)), f(g(a, b), h(c), 'd') FROM 'b' WHERE coalesce(y, z) = 1",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"sum(coalesce(x,0))", "f(g(a, b), h(c), 'd')"},
+				Conditions: []query.Condition{
+					{Operand1: "coalesce(y, z)", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with function calls with parens within quoted arguments works",
+			SQL:  "SELECT concat(a, ')'), concat('(', b, 'it\\'s)') FROM 'b'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"concat(a, ')')", "concat('(', b, 'it\\'s)')"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with aggregate functions works",
+			SQL:  "SELECT COUNT(*) AS total, sum(x), AVG(x), MIN(x), MAX(x) FROM 'b' GROUP BY a HAVING COUNT(*) > 1 ORDER BY count(*) DESC",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"COUNT(*)", "sum(x)", "AVG(x)", "MIN(x)", "MAX(x)"},
+				Aliases:   map[string]string{"COUNT(*)": "total"},
+				GroupBy:   []string{"a"},
+				Having: []query.Condition{
+					{Operand1: "COUNT(*)", Operand1IsField: true, Operator: query.Gt, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpNumber},
+				},
+				OrderBy: []query.OrderByField{{Field: "count(*)", Direction: query.Desc}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with structured fields works",
+			SQL:  "SELECT id, u.*, public.users.name, *, 'x', -1.5, NULL, substring(u.name, 0, 8), concat(a, ', ', now()), count(DISTINCT a) FROM public.users u",
+			Expected: query.Query{
+				Type:       query.Select,
+				Schema:     "public",
+				TableName:  "users",
+				TableAlias: "u",
+				Fields:     []string{"id", "u.*", "public.users.name", "*", "x", "-1.5", "NULL", "substring(u.name, 0, 8)", "concat(a, ', ', now())", "count(DISTINCT a)"},
+				FieldExprs: []query.FieldExpr{
+					{Type: query.Column, Text: "id", Name: "id"},
+					{Type: query.QualifiedColumn, Text: "u.*", Table: "u", Name: "*"},
+					{Type: query.QualifiedColumn, Text: "public.users.name", Table: "public.users", Name: "name"},
+					{Type: query.Column, Text: "*", Name: "*"},
+					{Type: query.Literal, Text: "'x'", Value: "x", ValueType: query.OpQuoted},
+					{Type: query.Literal, Text: "-1.5", Value: "-1.5", ValueType: query.OpNumber},
+					{Type: query.Literal, Text: "NULL", ValueType: query.OpNull},
+					{Type: query.FunctionCall, Text: "substring(u.name, 0, 8)", Name: "substring", Args: []query.FieldExpr{
+						{Type: query.QualifiedColumn, Text: "u.name", Table: "u", Name: "name"},
+						{Type: query.Literal, Text: "0", Value: "0", ValueType: query.OpNumber},
+						{Type: query.Literal, Text: "8", Value: "8", ValueType: query.OpNumber},
+					}},
+					{Type: query.FunctionCall, Text: "concat(a, ', ', now())", Name: "concat", Args: []query.FieldExpr{
+						{Type: query.Column, Text: "a", Name: "a"},
+						{Type: query.Literal, Text: "', '", Value: ", ", ValueType: query.OpQuoted},
+						{Type: query.FunctionCall, Text: "now()", Name: "now"},
+					}},
+					{Type: query.FunctionCall, Text: "count(DISTINCT a)", Name: "count", Args: []query.FieldExpr{
+						{Type: query.UnknownFieldExprType, Text: "DISTINCT a"},
+					}},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with arithmetic fields works",
+			SQL:  "SELECT price * quantity AS total, price*2, 1.5 + price / 3 - 2, round(a) * 'x', * FROM 'orders'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "orders",
+				Fields:    []string{"price * quantity", "price*2", "1.5 + price / 3 - 2", "round(a) * 'x'", "*"},
+				Aliases:   map[string]string{"price * quantity": "total"},
+				FieldExprs: []query.FieldExpr{
+					{Type: query.Arithmetic, Text: "price * quantity"},
+					{Type: query.Arithmetic, Text: "price*2"},
+					{Type: query.Arithmetic, Text: "1.5 + price / 3 - 2"},
+					{Type: query.Arithmetic, Text: "round(a) * 'x'"},
+					{Type: query.Column, Text: "*", Name: "*"},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with dangling arithmetic operator fails",
+			SQL:      "SELECT price * FROM 'orders'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected field or value after arithmetic operator"),
+		},
+		{
+			Name:     "SELECT COUNT(*) works",
+			SQL:      "SELECT COUNT(*) FROM 'b'",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"COUNT(*)"}},
+			Err:      nil,
+		},
+		{
+			Name:     "SELECT with unclosed function call fails",
+			SQL:      "SELECT round(avg(x), 2 FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected comma or FROM"),
+		},
+		{
+			Name:     "SELECT with DISTINCT after a field fails",
+			SQL:      "SELECT a, DISTINCT b FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: DISTINCT must come right after SELECT"),
+		},
+		{
+			Name:     "SELECT with empty WHERE fails",
+			SQL:      "SELECT a, c, d FROM 'b' WHERE",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a", "c", "d"}},
+			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+		},
+		{
+			Name:     "SELECT with WHERE with only operand fails",
+			SQL:      "SELECT a, c, d FROM 'b' WHERE a",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a", "c", "d"}},
+			Err:      fmt.Errorf("at WHERE: condition without operator"),
+		},
+		{
+			Name: "SELECT with WHERE with = works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a = ''",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with < works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a < '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Lt, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with <= works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a <= '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Lte, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with > works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a > '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Gt, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with >= works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a >= '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Gte, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with != works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Ne, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with != works (comparing field against another field)",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a != b",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Ne, Operand2: "b", Operand2IsField: true, Operand2Type: query.OpField},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT * works",
+			SQL:  "SELECT * FROM 'b'",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"*"},
+				Conditions: nil,
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT a, * works",
+			SQL:  "SELECT a, * FROM 'b'",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a", "*"},
+				Conditions: nil,
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with two conditions using AND works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1' AND b = '2'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Ne, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with two conditions using OR works",
+			SQL:  "SELECT a, c, d FROM 'b' WHERE a != '1' OR b = '2'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c", "d"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Ne, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE mixing AND and OR works",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' AND b = '2' or c = '3'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "3", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with fields starting like reserved words works",
+			SQL:  "SELECT origin, android FROM 'b' WHERE origin = android",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"origin", "android"},
+				Conditions: []query.Condition{
+					{Operand1: "origin", Operand1IsField: true, Operator: query.Eq, Operand2: "android", Operand2IsField: true, Operand2Type: query.OpField},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with parenthesized group works",
+			SQL:  "SELECT a FROM 'b' WHERE (a = '1' OR b = '2') AND c = '3'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "3", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				WhereExpr: &query.WhereExpr{
+					Type: query.And,
+					Children: []query.WhereExpr{
+						{Type: query.Group, Children: []query.WhereExpr{
+							{Type: query.Or, Children: []query.WhereExpr{
+								{Type: query.Leaf, Condition: &query.Condition{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true}},
+								{Type: query.Leaf, Condition: &query.Condition{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted}},
+							}},
+						}},
+						{Type: query.Leaf, Condition: &query.Condition{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "3", Operand2IsField: false, Operand2Type: query.OpQuoted}},
+					},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with nested parenthesized groups works",
+			SQL:  "SELECT a FROM 'b' WHERE (a = '1' OR (b = '2' AND c = '3')) AND d = '4' ORDER BY a",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "3", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "4", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				WhereExpr: &query.WhereExpr{
+					Type: query.And,
+					Children: []query.WhereExpr{
+						{Type: query.Group, Children: []query.WhereExpr{
+							{Type: query.Or, Children: []query.WhereExpr{
+								{Type: query.Leaf, Condition: &query.Condition{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true}},
+								{Type: query.Group, Children: []query.WhereExpr{
+									{Type: query.And, Children: []query.WhereExpr{
+										{Type: query.Leaf, Condition: &query.Condition{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted}},
+										{Type: query.Leaf, Condition: &query.Condition{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "3", Operand2IsField: false, Operand2Type: query.OpQuoted}},
+									}},
+								}},
+							}},
+						}},
+						{Type: query.Leaf, Condition: &query.Condition{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "4", Operand2IsField: false, Operand2Type: query.OpQuoted}},
+					},
+				},
+				OrderBy: []query.OrderByField{{Field: "a", Direction: query.Asc}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with unclosed parens fails",
+			SQL:      "SELECT a FROM 'b' WHERE (a = '1' OR b = '2'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected closing parens"),
+		},
+		{
+			Name:     "SELECT with WHERE with unclosed parens before ORDER BY fails",
+			SQL:      "SELECT a FROM 'b' WHERE ((a = '1') ORDER BY a",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected closing parens"),
+		},
+		{
+			Name:     "SELECT with WHERE with unopened parens fails",
+			SQL:      "SELECT a FROM 'b' WHERE (a = '1')) AND b = '2'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: unexpected closing parens"),
+		},
+		{
+			Name:     "SELECT with WHERE ending in opening parens fails",
+			SQL:      "SELECT a FROM 'b' WHERE a = '1' AND (",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected condition after opening parens"),
+		},
+		{
+			Name:     "SELECT with WHERE with empty parens fails",
+			SQL:      "SELECT a FROM 'b' WHERE a = '1' AND ()",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected field"),
+		},
+		{
+			Name:     "SELECT with WHERE ending in AND after parens fails",
+			SQL:      "SELECT a FROM 'b' WHERE (a = '1') AND",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected condition after AND/OR"),
+		},
+		{
+			Name: "SELECT with WHERE with negated conditions works",
+			SQL:  "SELECT a FROM 'b' WHERE NOT active = '1' AND NOT c NOT IN ('2') OR NOT NOT d IS NULL",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "active", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, Negated: true},
+					{Operand1: "c", Operand1IsField: true, Operator: query.NotIn, Operand2Type: query.OpList, Operand2List: []string{"2"}, Operand2ListTypes: []query.OperandType{query.OpQuoted}, Negated: true, OrWithNext: true},
+					{Operand1: "d", Operand1IsField: true, Operator: query.IsNull},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with negated group works",
+			SQL:  "SELECT a FROM 'b' WHERE NOT (a = '1' OR b = '2')",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				WhereExpr: &query.WhereExpr{
+					Type:    query.Group,
+					Negated: true,
+					Children: []query.WhereExpr{
+						{Type: query.Or, Children: []query.WhereExpr{
+							{Type: query.Leaf, Condition: &query.Condition{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true}},
+							{Type: query.Leaf, Condition: &query.Condition{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted}},
+						}},
+					},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE ending in NOT fails",
+			SQL:      "SELECT a FROM 'b' WHERE a = '1' AND NOT",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected condition after NOT"),
+		},
+		{
+			Name:     "SELECT with WHERE with NOT before operator fails",
+			SQL:      "SELECT a FROM 'b' WHERE NOT = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected field"),
+		},
+		{
+			Name:     "SELECT with WHERE ending in OR fails",
+			SQL:      "SELECT a FROM 'b' WHERE a = '1' OR",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected condition after AND/OR"),
+		},
+		{
+			Name:     "SELECT with WHERE with unknown connector fails",
+			SQL:      "SELECT a FROM 'b' WHERE a = '1' XOR b = '2'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("expected AND or OR"),
+		},
+		{
+			Name: "SELECT with WHERE with number works",
+			SQL:  "SELECT a FROM 'b' WHERE a = 1 AND c > -2.5",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpNumber},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Gt, Operand2: "-2.5", Operand2IsField: false, Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with IN and quoted values works",
+			SQL:  "SELECT a FROM 'b' WHERE a IN ('x', 'y')",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.In, Operand2Type: query.OpList, Operand2List: []string{"x", "y"}, Operand2ListTypes: []query.OperandType{query.OpQuoted, query.OpQuoted}},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with IN and numbers works",
+			SQL:  "SELECT a FROM 'b' WHERE id IN (1,2, 3) AND c = '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "id", Operand1IsField: true, Operator: query.In, Operand2Type: query.OpList, Operand2List: []string{"1", "2", "3"}, Operand2ListTypes: []query.OperandType{query.OpNumber, query.OpNumber, query.OpNumber}},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with NOT IN works",
+			SQL:  "SELECT a FROM 'b' WHERE status NOT IN (1, 2, 3) OR c = '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "status", Operand1IsField: true, Operator: query.NotIn, Operand2Type: query.OpList, Operand2List: []string{"1", "2", "3"}, Operand2ListTypes: []query.OperandType{query.OpNumber, query.OpNumber, query.OpNumber}, OrWithNext: true},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with NOT without IN fails",
+			SQL:      "SELECT a FROM 'b' WHERE status NOT = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected IN or LIKE after NOT"),
+		},
+		{
+			Name:     "SELECT with WHERE with empty NOT IN fails",
+			SQL:      "SELECT a FROM 'b' WHERE status NOT IN ()",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty IN list"),
+		},
+		{
+			Name: "SELECT with WHERE with BETWEEN works",
+			SQL:  "SELECT a FROM 'b' WHERE age BETWEEN '18' AND 65 AND c = '1'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "age", Operand1IsField: true, Operator: query.Between, Operand2: "18", Operand2Type: query.OpQuoted, Operand3: "65", Operand3Type: query.OpNumber},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with BETWEEN missing upper bound fails",
+			SQL:      "SELECT a FROM 'b' WHERE age BETWEEN '18' AND",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected BETWEEN lower AND upper bounds"),
+		},
+		{
+			Name:     "SELECT with WHERE with BETWEEN with field as upper bound fails",
+			SQL:      "SELECT a FROM 'b' WHERE age BETWEEN '18' AND c = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted value or number as BETWEEN upper bound"),
+		},
+		{
+			Name: "SELECT with WHERE comparing two fields works",
+			SQL:  "SELECT a FROM orders WHERE total > paid AND b <> e5 OR c <= d",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "orders",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "total", Operand1IsField: true, Operator: query.Gt, Operand2: "paid", Operand2IsField: true, Operand2Type: query.OpField},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Ne, Operand2: "e5", Operand2IsField: true, Operand2Type: query.OpField, OrWithNext: true},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Lte, Operand2: "d", Operand2IsField: true, Operand2Type: query.OpField},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE comparing two qualified fields works",
+			SQL:  "SELECT a FROM public.orders o WHERE orders.total > o.paid AND public.orders.total >= 1e5",
+			Expected: query.Query{
+				Type:       query.Select,
+				Schema:     "public",
+				TableName:  "orders",
+				TableAlias: "o",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "orders.total", Operand1IsField: true, Operator: query.Gt, Operand2: "o.paid", Operand2IsField: true, Operand2Type: query.OpField},
+					{Operand1: "public.orders.total", Operand1IsField: true, Operator: query.Gte, Operand2: "1e5", Operand2IsField: false, Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE comparing to a field of an unknown table fails",
+			SQL:      "SELECT a FROM orders o WHERE o.total > x.paid",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: unknown table or alias x in field x.paid"),
+		},
+		{
+			Name: "SELECT with WHERE EXISTS works",
+			SQL:  "SELECT a FROM 'a' WHERE EXISTS (SELECT 1 FROM 'b' WHERE b.x = a.id) AND c = 1",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "a",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{
+						Operator: query.Exists,
+						Subquery: &query.Query{
+							Type:      query.Select,
+							TableName: "b",
+							Fields:    []string{"1"},
+							Conditions: []query.Condition{
+								{Operand1: "b.x", Operand1IsField: true, Operator: query.Eq, Operand2: "a.id", Operand2IsField: true, Operand2Type: query.OpField},
+							},
+						},
+					},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE NOT EXISTS correlated by alias works",
+			SQL:  "SELECT u.name FROM users u WHERE u.active = 1 AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.user_id = u.id)",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "users",
+				TableAlias: "u",
+				Fields:     []string{"u.name"},
+				Conditions: []query.Condition{
+					{Operand1: "u.active", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
+					{
+						Operator: query.NotExists,
+						Subquery: &query.Query{
+							Type:       query.Select,
+							TableName:  "orders",
+							TableAlias: "o",
+							Fields:     []string{"1"},
+							Conditions: []query.Condition{
+								{Operand1: "o.user_id", Operand1IsField: true, Operator: query.Eq, Operand2: "u.id", Operand2IsField: true, Operand2Type: query.OpField},
+							},
+						},
+					},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE EXISTS without parens fails",
+			SQL:      "SELECT a FROM 'a' WHERE EXISTS SELECT 1 FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected opening parens after EXISTS"),
+		},
+		{
+			Name:     "SELECT with WHERE EXISTS without closing parens fails",
+			SQL:      "SELECT a FROM 'a' WHERE EXISTS (SELECT 1 FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected closing parens after subquery"),
+		},
+		{
+			Name:     "SELECT with WHERE EXISTS of a query other than SELECT fails",
+			SQL:      "SELECT a FROM 'a' WHERE EXISTS (DELETE FROM 'b' WHERE c = 1)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected SELECT in subquery"),
+		},
+		{
+			Name:     "SELECT with WHERE EXISTS with an invalid subquery fails",
+			SQL:      "SELECT a FROM users u WHERE EXISTS (SELECT 1 FROM orders o WHERE o.user_id = x.id)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: unknown table or alias x in field x.id"),
+		},
+		{
+			Name: "SELECT with WHERE with a scalar subquery works",
+			SQL:  "SELECT a FROM 'a' WHERE a = (SELECT max(x) FROM 'b') AND c > 1",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "a",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{
+						Operand1:        "a",
+						Operand1IsField: true,
+						Operator:        query.Eq,
+						Operand2Type:    query.OpSubquery,
+						Subquery:        &query.Query{Type: query.Select, TableName: "b", Fields: []string{"max(x)"}},
+					},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Gt, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with IN and NOT IN subqueries works",
+			SQL:  "SELECT a FROM 'a' WHERE a IN (SELECT id FROM 'b' WHERE c = ?) OR d NOT IN ( select id from 'e' )",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "a",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{
+						Operand1:        "a",
+						Operand1IsField: true,
+						Operator:        query.In,
+						Operand2Type:    query.OpSubquery,
+						Subquery: &query.Query{
+							Type:      query.Select,
+							TableName: "b",
+							Fields:    []string{"id"},
+							Conditions: []query.Condition{
+								{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "?", Operand2Type: query.OpPlaceholder},
+							},
+						},
+						OrWithNext: true,
+					},
+					{
+						Operand1:        "d",
+						Operand1IsField: true,
+						Operator:        query.NotIn,
+						Operand2Type:    query.OpSubquery,
+						Subquery:        &query.Query{Type: query.Select, TableName: "e", Fields: []string{"id"}},
+					},
+				},
+				Params: []query.Param{{Index: 1, Placeholder: "?"}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with a scalar subquery other than SELECT fails",
+			SQL:      "SELECT a FROM 'a' WHERE a = (UPDATE 'b' SET c = 1 WHERE d = 2)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected SELECT in subquery"),
+		},
+		{
+			Name:     "SELECT with WHERE with an unclosed scalar subquery fails",
+			SQL:      "SELECT a FROM 'a' WHERE a = (SELECT max(x) FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected closing parens after subquery"),
+		},
+		{
+			Name: "SELECT FROM a derived table works",
+			SQL:  "SELECT sub.x FROM (SELECT a AS x FROM 'b' WHERE c = ?) sub WHERE sub.x > ?",
+			Expected: query.Query{
+				Type: query.Select,
+				FromSubquery: &query.Query{
+					Type:      query.Select,
+					TableName: "b",
+					Fields:    []string{"a"},
+					Aliases:   map[string]string{"a": "x"},
+					Conditions: []query.Condition{
+						{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "?", Operand2Type: query.OpPlaceholder},
+					},
+				},
+				TableAlias: "sub",
+				Fields:     []string{"sub.x"},
+				Conditions: []query.Condition{
+					{Operand1: "sub.x", Operand1IsField: true, Operator: query.Gt, Operand2: "?", Operand2Type: query.OpPlaceholder},
+				},
+				Params: []query.Param{{Index: 1, Placeholder: "?"}, {Index: 2, Placeholder: "?"}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT FROM a derived table without alias fails",
+			SQL:      "SELECT x FROM (SELECT a AS x FROM 'b') WHERE x > 1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected alias for derived table"),
+		},
+		{
+			Name:     "SELECT FROM a derived table other than SELECT fails",
+			SQL:      "SELECT x FROM (DELETE FROM 'b' WHERE a = 1) AS sub",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected SELECT in subquery"),
+		},
+		{
+			Name:     "SELECT FROM an unclosed derived table fails",
+			SQL:      "SELECT x FROM (SELECT a AS x FROM 'b' AS sub",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected closing parens after subquery"),
+		},
+		{
+			Name: "SELECT with WHERE with LIKE and NOT LIKE works",
+			SQL:  "SELECT a FROM 'b' WHERE name LIKE '%fo_o%' AND name NOT LIKE 'bar%'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "name", Operand1IsField: true, Operator: query.Like, Operand2: "%fo_o%", Operand2Type: query.OpQuoted},
+					{Operand1: "name", Operand1IsField: true, Operator: query.NotLike, Operand2: "bar%", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with LIKE with ESCAPE works",
+			SQL:  "SELECT a FROM 'b' WHERE name LIKE 'a!_b%' escape '!' AND c NOT LIKE 'd\\_e' ESCAPE '\\\\' COLLATE utf8_bin AND f LIKE 'g'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "name", Operand1IsField: true, Operator: query.Like, Operand2: "a!_b%", Operand2Type: query.OpQuoted, Escape: "!"},
+					{Operand1: "c", Operand1IsField: true, Operator: query.NotLike, Operand2: "d\\_e", Operand2Type: query.OpQuoted, Escape: "\\", Collation: "utf8_bin"},
+					{Operand1: "f", Operand1IsField: true, Operator: query.Like, Operand2: "g", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE with LIKE with ESCAPE of an unescaped backslash works",
+			SQL:  "SELECT a FROM 'b' WHERE c LIKE 'a\\_b' ESCAPE '\\' AND d LIKE 'e' ESCAPE '\\''",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Like, Operand2: "a\\_b", Operand2Type: query.OpQuoted, Escape: "\\"},
+					{Operand1: "d", Operand1IsField: true, Operator: query.Like, Operand2: "e", Operand2Type: query.OpQuoted, Escape: "'"},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with LIKE with ESCAPE of many characters fails",
+			SQL:      "SELECT a FROM 'b' WHERE name LIKE 'a!_b%' ESCAPE '!!'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted single character after ESCAPE"),
+		},
+		{
+			Name:     "SELECT with WHERE with LIKE with ESCAPE without character fails",
+			SQL:      "SELECT a FROM 'b' WHERE name LIKE 'a!_b%' ESCAPE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted single character after ESCAPE"),
+		},
+		{
+			Name:     "SELECT with WHERE with LIKE and unquoted pattern fails",
+			SQL:      "SELECT a FROM 'b' WHERE name LIKE c",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted pattern after LIKE"),
+		},
+		{
+			Name:     "SELECT with WHERE with LIKE without pattern fails",
+			SQL:      "SELECT a FROM 'b' WHERE name NOT LIKE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted pattern after LIKE"),
+		},
+		{
+			Name: "SELECT with WHERE with COLLATE works",
+			SQL:  "SELECT a FROM 'b' WHERE name = 'abc' COLLATE utf8_bin AND c LIKE 'd%' collate utf8mb4_general_ci OR e = 1",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "name", Operand1IsField: true, Operator: query.Eq, Operand2: "abc", Operand2Type: query.OpQuoted, Collation: "utf8_bin"},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Like, Operand2: "d%", Operand2Type: query.OpQuoted, Collation: "utf8mb4_general_ci", OrWithNext: true},
+					{Operand1: "e", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with COLLATE without collation name fails",
+			SQL:      "SELECT a FROM 'b' WHERE name = 'abc' COLLATE AND c = 1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected collation name after COLLATE"),
+		},
+		{
+			Name:     "SELECT with WHERE with COLLATE after IS NULL fails",
+			SQL:      "SELECT a FROM 'b' WHERE name IS NULL COLLATE utf8_bin",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("expected AND or OR"),
+		},
+		{
+			Name: "SELECT with WHERE with IS NULL and IS NOT NULL works",
+			SQL:  "SELECT a FROM 'b' WHERE deleted_at IS NULL AND created_at is not null",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "deleted_at", Operand1IsField: true, Operator: query.IsNull},
+					{Operand1: "created_at", Operand1IsField: true, Operator: query.IsNotNull},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with WHERE with IS followed by a value fails",
+			SQL:      "SELECT a FROM 'b' WHERE deleted_at IS '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected NULL or NOT NULL after IS"),
+		},
+		{
+			Name:     "SELECT with WHERE with IS NOT followed by a value fails",
+			SQL:      "SELECT a FROM 'b' WHERE deleted_at IS NOT '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected NULL or NOT NULL after IS"),
+		},
+		{
+			Name:     "SELECT with WHERE with operator but no right side operand fails",
+			SQL:      "SELECT a FROM 'b' WHERE a =",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: condition without right side operand"),
+		},
+		{
+			Name:     "SELECT with WHERE with empty IN fails",
+			SQL:      "SELECT a FROM 'b' WHERE a IN ()",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty IN list"),
+		},
+		{
+			Name:     "SELECT with WHERE with IN without list fails",
+			SQL:      "SELECT a FROM 'b' WHERE a IN",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty IN list"),
+		},
+		{
+			Name:     "SELECT with WHERE with unterminated IN fails",
+			SQL:      "SELECT a FROM 'b' WHERE a IN ('1', '2'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected closing parens in IN list"),
+		},
+		{
+			Name:     "SELECT with WHERE with field in IN list fails",
+			SQL:      "SELECT a FROM 'b' WHERE a IN (c)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted value or number in IN list"),
+		},
+		{
+			Name: "SELECT with GROUP BY works",
+			SQL:  "SELECT dept, count(id) FROM 'emp' GROUP BY dept",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "emp",
+				Fields:    []string{"dept", "count(id)"},
+				GroupBy:   []string{"dept"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE, GROUP BY with many fields and ORDER BY works",
+			SQL:  "SELECT dept, role, max(salary) FROM 'emp' WHERE a = '1' group by dept, role ORDER BY dept",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "emp",
+				Fields:    []string{"dept", "role", "max(salary)"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				GroupBy: []string{"dept", "role"},
+				OrderBy: []query.OrderByField{{Field: "dept", Direction: query.Asc}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with GROUP BY and HAVING works",
+			SQL:  "SELECT dept, count(id) FROM 'emp' WHERE a = '1' GROUP BY dept HAVING count(id) > '5' OR max(salary) IN (1, 2) ORDER BY dept",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "emp",
+				Fields:    []string{"dept", "count(id)"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				GroupBy: []string{"dept"},
+				Having: []query.Condition{
+					{Operand1: "count(id)", Operand1IsField: true, Operator: query.Gt, Operand2: "5", Operand2IsField: false, Operand2Type: query.OpQuoted, OrWithNext: true},
+					{Operand1: "max(salary)", Operand1IsField: true, Operator: query.In, Operand2Type: query.OpList, Operand2List: []string{"1", "2"}, Operand2ListTypes: []query.OperandType{query.OpNumber, query.OpNumber}},
+				},
+				OrderBy: []query.OrderByField{{Field: "dept", Direction: query.Asc}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with HAVING without GROUP BY fails",
+			SQL:      "SELECT count(id) FROM 'emp' HAVING count(id) > '5'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at HAVING: HAVING requires GROUP BY"),
+		},
+		{
+			Name:     "SELECT with empty HAVING fails",
+			SQL:      "SELECT dept FROM 'emp' GROUP BY dept HAVING",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at HAVING: empty HAVING clause"),
+		},
+		{
+			Name:     "SELECT with HAVING with only operand fails",
+			SQL:      "SELECT dept FROM 'emp' GROUP BY dept HAVING count(id)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at HAVING: condition without operator"),
+		},
+		{
+			Name:     "SELECT with GROUP BY with trailing comma fails",
+			SQL:      "SELECT dept FROM 'emp' GROUP BY dept,",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at GROUP BY: expected field to GROUP BY"),
+		},
+		{
+			Name:     "SELECT with GROUP BY with trailing comma before ORDER BY fails",
+			SQL:      "SELECT dept FROM 'emp' GROUP BY dept, ORDER BY dept",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at GROUP BY: expected field to GROUP BY"),
+		},
+		{
+			Name:     "SELECT with GROUP BY after ORDER BY fails",
+			SQL:      "SELECT dept FROM 'emp' ORDER BY dept GROUP BY dept",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected ASC, DESC or comma"),
+		},
+		{
+			Name: "SELECT with ORDER BY works",
+			SQL:  "SELECT a, b FROM 'b' ORDER BY a",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "b"},
+				OrderBy:   []query.OrderByField{{Field: "a", Direction: query.Asc}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE and ORDER BY with many fields and directions works",
+			SQL:  "SELECT a, b FROM 'b' WHERE a = '1' order by a ASC, b desc, c",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "b"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				OrderBy: []query.OrderByField{
+					{Field: "a", Direction: query.Asc},
+					{Field: "b", Direction: query.Desc},
+					{Field: "c", Direction: query.Asc},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with empty ORDER BY fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected field to ORDER BY"),
+		},
+		{
+			Name:     "SELECT with ORDER BY with trailing comma fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY a,",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected field to ORDER BY"),
+		},
+		{
+			Name: "SELECT with ORDER BY with ordinals works",
+			SQL:  "SELECT a, b FROM 'b' ORDER BY a, 2 DESC",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "b"},
+				OrderBy: []query.OrderByField{
+					{Field: "a", Direction: query.Asc},
+					{Ordinal: 2, Direction: query.Desc},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with ORDER BY with NULLS FIRST and NULLS LAST works",
+			SQL:  "SELECT a, b FROM 'b' ORDER BY a DESC NULLS LAST, b nulls  first, c",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "b"},
+				OrderBy: []query.OrderByField{
+					{Field: "a", Direction: query.Desc, Nulls: query.NullsLast},
+					{Field: "b", Direction: query.Asc, Nulls: query.NullsFirst},
+					{Field: "c", Direction: query.Asc},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with ORDER BY with NULLS but no FIRST or LAST fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY a NULLS MIDDLE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected FIRST or LAST after NULLS"),
+		},
+		{
+			Name:     "SELECT with ORDER BY ending in NULLS fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY a ASC NULLS",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected FIRST or LAST after NULLS"),
+		},
+		{
+			Name: "SELECT with signed and decimal numbers works",
+			SQL:  "SELECT a FROM 'b' WHERE c = -3.14 AND d > .5 AND e < 1. AND f != +2",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "-3.14", Operand2IsField: false, Operand2Type: query.OpNumber},
+					{Operand1: "d", Operand1IsField: true, Operator: query.Gt, Operand2: ".5", Operand2IsField: false, Operand2Type: query.OpNumber},
+					{Operand1: "e", Operand1IsField: true, Operator: query.Lt, Operand2: "1.", Operand2IsField: false, Operand2Type: query.OpNumber},
+					{Operand1: "f", Operand1IsField: true, Operator: query.Ne, Operand2: "+2", Operand2IsField: false, Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with numbers in scientific notation works",
+			SQL:  "SELECT a FROM 'b' WHERE c = 1.5e10 AND d BETWEEN 2E-3 AND 1e+3",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "1.5e10", Operand2IsField: false, Operand2Type: query.OpNumber},
+					{Operand1: "d", Operand1IsField: true, Operator: query.Between, Operand2: "2E-3", Operand2Type: query.OpNumber, Operand3: "1e+3", Operand3Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with number without exponent digits fails",
+			SQL:      "SELECT a FROM 'b' WHERE c = 1e",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted value"),
+		},
+		{
+			Name:     "UPDATE with number without exponent digits fails",
+			SQL:      "UPDATE 'a' SET b = 2E- WHERE c = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+		},
+		{
+			Name: "INSERT with numbers in scientific notation works",
+			SQL:  "INSERT INTO 'a' (b, c) VALUES (1e5, -2.5E-3)",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c"},
+				Inserts:     [][]string{{"1e5", "-2.5E-3"}},
+				InsertTypes: [][]query.OperandType{{query.OpNumber, query.OpNumber}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with number with many dots fails",
+			SQL:      "SELECT a FROM 'b' WHERE c = 1.2.3",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted value"),
+		},
+		{
+			Name:     "INSERT with doubly signed number fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES (-+1)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: expected quoted value, number or NULL"),
+		},
+		{
+			Name: "UPDATE with signed and decimal numbers works",
+			SQL:  "UPDATE 'a' SET b = -.5, c = 2. WHERE d = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "-.5", "c": "2."},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpNumber, "c": query.OpNumber},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with ORDER BY with zero ordinal fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY 0",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected ordinal to be a positive integer"),
+		},
+		{
+			Name:     "SELECT with ORDER BY with negative ordinal fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY a, -1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected ordinal to be a positive integer"),
+		},
+		{
+			Name:     "SELECT with ORDER BY with decimal ordinal fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY 1.5",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected ordinal to be a positive integer"),
+		},
+		{
+			Name:     "SELECT with ORDER BY with unknown direction fails",
+			SQL:      "SELECT a FROM 'b' ORDER BY a UP",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ORDER BY: expected ASC, DESC or comma"),
+		},
+		{
+			Name: "SELECT with LIMIT works",
+			SQL:  "SELECT a FROM 'b' LIMIT 10",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Limit:     intPtr(10),
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with WHERE, ORDER BY, LIMIT and OFFSET works",
+			SQL:  "SELECT a FROM 'b' WHERE a = '1' ORDER BY a LIMIT 10 OFFSET 20",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				OrderBy: []query.OrderByField{{Field: "a", Direction: query.Asc}},
+				Limit:   intPtr(10),
+				Offset:  intPtr(20),
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with MySQL's LIMIT offset, limit shorthand works",
+			SQL:  "SELECT a FROM 'b' limit 20, 10",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Limit:     intPtr(10),
+				Offset:    intPtr(20),
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with TOP works",
+			SQL:  "SELECT TOP 10 a FROM 'b'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Limit:     intPtr(10),
+				Top:       true,
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT DISTINCT with TOP and OFFSET works",
+			SQL:  "select distinct top 5 a, c FROM 'b' ORDER BY a OFFSET 20",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a", "c"},
+				Distinct:  true,
+				OrderBy:   []query.OrderByField{{Field: "a", Direction: query.Asc}},
+				Limit:     intPtr(5),
+				Top:       true,
+				Offset:    intPtr(20),
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with non-integer TOP fails",
+			SQL:      "SELECT TOP a FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected non-negative integer after TOP"),
+		},
+		{
+			Name:     "SELECT with TOP without value fails",
+			SQL:      "SELECT TOP",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected non-negative integer after TOP"),
+		},
+		{
+			Name:     "SELECT with TOP and LIMIT fails",
+			SQL:      "SELECT TOP 10 a FROM 'b' LIMIT 5",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at LIMIT: limit already specified in TOP"),
+		},
+		{
+			Name:     "SELECT with quoted LIMIT fails",
+			SQL:      "SELECT a FROM 'b' LIMIT '10'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at LIMIT: expected non-negative integer"),
+		},
+		{
+			Name:     "SELECT with negative LIMIT fails",
+			SQL:      "SELECT a FROM 'b' LIMIT -10",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at LIMIT: expected non-negative integer"),
+		},
+		{
+			Name:     "SELECT with empty OFFSET fails",
+			SQL:      "SELECT a FROM 'b' LIMIT 10 OFFSET",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at OFFSET: expected non-negative integer"),
+		},
+		{
+			Name:     "SELECT with LIMIT shorthand and OFFSET fails",
+			SQL:      "SELECT a FROM 'b' LIMIT 20, 10 OFFSET 5",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at OFFSET: offset already specified in LIMIT"),
+		},
+		{
+			Name:     "SELECT with LIMIT before ORDER BY fails",
+			SQL:      "SELECT a FROM 'b' LIMIT 10 ORDER BY a",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at LIMIT: expected OFFSET"),
+		},
+		{
+			Name:     "Empty UPDATE fails",
+			SQL:      "UPDATE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name fails",
+			SQL:      "UPDATE 'a'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name and SET fails",
+			SQL:      "UPDATE 'a' SET",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name: "UPDATE keeps the assignments in order as pairs",
+			SQL:  "UPDATE 'a' SET c = '1', b = 2, c = c + 1 WHERE d = '3'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "2", "c": "c + 1"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpNumber, "c": query.OpExpression},
+				UpdatePairs: []query.UpdatePair{
+					{Field: "c", Value: "1", Type: query.OpQuoted},
+					{Field: "b", Value: "2", Type: query.OpNumber},
+					{Field: "c", Value: "c + 1", Type: query.OpExpression},
+				},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "3", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE without assignments fails",
+			SQL:      "UPDATE 'a' SET WHERE b = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected at least one assignment"),
+		},
+		{
+			Name:     "UPDATE without assignments and with RETURNING fails",
+			SQL:      "UPDATE 'a' SET WHERE b = '1' RETURNING b",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected at least one assignment"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name, SET with a field but no value and WHERE fails",
+			SQL:      "UPDATE 'a' SET b WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected '='"),
+		},
+		{
+			Name:     "Incomplete UPDATE with table name, SET with a field and = but no value and WHERE fails",
+			SQL:      "UPDATE 'a' SET b = WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+		},
+		{
+			Name:     "Incomplete UPDATE due to no WHERE clause fails",
+			SQL:      "UPDATE 'a' SET b = 'hello' WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+		},
+		{
+			Name:     "Incomplete UPDATE due incomplete WHERE clause fails",
+			SQL:      "UPDATE 'a' SET b = 'hello' WHERE a",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: condition without operator"),
+		},
+		{
+			Name: "UPDATE works",
+			SQL:  "UPDATE 'a' SET b = 'hello' WHERE a = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "hello"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE works with simple quote inside",
+			SQL:  "UPDATE 'a' SET b = 'hello\\'world' WHERE a = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "hello\\'world"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE works with doubled quotes inside",
+			SQL:  "UPDATE 'a' SET b = 'it''s ''quoted''' WHERE a = 'don''t'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "it's 'quoted'"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "don't", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE with unterminated quoted value fails",
+			SQL:      "UPDATE 'a' SET b = 'it''s",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected quoted value"),
+		},
+		{
+			Name: "UPDATE with multiple SETs works",
+			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "hello", "c": "bye"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted, "c": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with multiple SETs and multiple conditions works",
+			SQL:  "UPDATE 'a' SET b = 'hello', c = 'bye' WHERE a = '1' AND b = '789'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "hello", "c": "bye"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted, "c": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "789", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with unquoted table name works",
+			SQL:  "UPDATE users SET b = 'hello' WHERE a = '1'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "users",
+				Updates:     map[string]string{"b": "hello"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE with reserved word as table name fails",
+			SQL:      "UPDATE SET b = 'hello' WHERE a = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected table name"),
+		},
+		{
+			Name: "UPDATE with NULL works",
+			SQL:  "UPDATE 'a' SET b = NULL, c = '' WHERE d = null",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "", "c": ""},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpNull, "c": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "", Operand2IsField: false, Operand2Type: query.OpNull},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE with NULL as field fails",
+			SQL:      "UPDATE 'a' SET NULL = 'a' WHERE d = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected at least one field to update"),
+		},
+		{
+			Name:     "SELECT with NULL in IN list fails",
+			SQL:      "SELECT a FROM 'b' WHERE a IN ('1', NULL)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted value or number in IN list"),
+		},
+		{
+			Name: "UPDATE with fields and expressions works",
+			SQL:  "UPDATE 'a' SET counter = counter + '1', b = c, d = e/2 - f WHERE id = '5'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"counter": "counter + '1'", "b": "c", "d": "e/2 - f"},
+				UpdateTypes: map[string]query.OperandType{"counter": query.OpExpression, "b": query.OpField, "d": query.OpExpression},
+				Conditions: []query.Condition{
+					{Operand1: "id", Operand1IsField: true, Operator: query.Eq, Operand2: "5", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with numbers works",
+			SQL:  "UPDATE 'a' SET a = 'x', b = 10, c = -3.5 WHERE id = 5",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"a": "x", "b": "10", "c": "-3.5"},
+				UpdateTypes: map[string]query.OperandType{"a": query.OpQuoted, "b": query.OpNumber, "c": query.OpNumber},
+				Conditions: []query.Condition{
+					{Operand1: "id", Operand1IsField: true, Operator: query.Eq, Operand2: "5", Operand2IsField: false, Operand2Type: query.OpNumber},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE with incomplete expression fails",
+			SQL:      "UPDATE 'a' SET counter = counter + WHERE id = '5'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UPDATE: expected field or value after arithmetic operator"),
+		},
+		{
+			Name:     "Empty DELETE fails",
+			SQL:      "DELETE FROM",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name:     "DELETE without WHERE fails",
+			SQL:      "DELETE FROM 'a'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name:     "DELETE with empty WHERE fails",
+			SQL:      "DELETE FROM 'a' WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: empty WHERE clause"),
+		},
+		{
+			Name:     "DELETE with WHERE with field but no operator fails",
+			SQL:      "DELETE FROM 'a' WHERE b",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: condition without operator"),
+		},
+		{
+			Name: "DELETE with WHERE works",
+			SQL:  "DELETE FROM 'a' WHERE b = '1'",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "a",
+				Conditions: []query.Condition{
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "DELETE with WHERE and LIMIT works",
+			SQL:  "DELETE FROM 'a' WHERE b = '1' LIMIT 10",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "a",
+				Conditions: []query.Condition{
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				Limit: intPtr(10),
+			},
+			Err: nil,
+		},
+		{
+			Name:     "DELETE with LIMIT but no WHERE fails",
+			SQL:      "DELETE FROM 'a' LIMIT 10",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name:     "DELETE with LIMIT offset shorthand fails",
+			SQL:      "DELETE FROM 'a' WHERE b = '1' LIMIT 10, 20",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at LIMIT: unexpected token after LIMIT"),
+		},
+		{
+			Name:     "DELETE with OFFSET fails",
+			SQL:      "DELETE FROM 'a' WHERE b = '1' LIMIT 10 OFFSET 20",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at LIMIT: unexpected token after LIMIT"),
+		},
+		{
+			Name: "DELETE with unquoted table name works",
+			SQL:  "DELETE FROM users WHERE b = '1'",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "users",
+				Conditions: []query.Condition{
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "REPLACE works",
+			SQL:  "REPLACE INTO 'a' (b, c) VALUES ('1', 2)",
+			Expected: query.Query{
+				Type:        query.Replace,
+				TableName:   "a",
+				Fields:      []string{"b", "c"},
+				Inserts:     [][]string{{"1", "2"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted, query.OpNumber}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "REPLACE with SELECT works",
+			SQL:  "replace into a (b) SELECT b FROM c",
+			Expected: query.Query{
+				Type:      query.Replace,
+				TableName: "a",
+				Fields:    []string{"b"},
+				InsertSelect: &query.Query{
+					Type:      query.Select,
+					TableName: "c",
+					Fields:    []string{"b"},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "REPLACE with value count not matching field count fails",
+			SQL:      "REPLACE INTO 'a' (b, c) VALUES ('1', '2'), ('3')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at REPLACE INTO: row 2 has 1 values but 2 fields"),
+		},
+		{
+			Name:     "INSERT with more values than fields fails",
+			SQL:      "INSERT INTO 'a' (b, c, d) VALUES ('1', '2', '3'), ('4', '5', '6'), ('7', '8', '9', '10')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: row 3 has 4 values but 3 fields"),
+		},
+		{
+			Name:     "INSERT with fewer values than fields fails",
+			SQL:      "INSERT INTO 'a' (b, c, d) VALUES ('1', '2'), ('4', '5', '6')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: row 1 has 2 values but 3 fields"),
+		},
+		{
+			Name:     "REPLACE with no rows to insert fails",
+			SQL:      "REPLACE INTO 'a' (b)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at REPLACE INTO: need at least one row to insert"),
+		},
+		{
+			Name: "INSERT with ON DUPLICATE KEY UPDATE works",
+			SQL:  "INSERT INTO 'a' (b, c) VALUES ('1', 2) ON DUPLICATE KEY UPDATE b = '2', c = c + 1",
+			Expected: query.Query{
+				Type:             query.Insert,
+				TableName:        "a",
+				Fields:           []string{"b", "c"},
+				Inserts:          [][]string{{"1", "2"}},
+				InsertTypes:      [][]query.OperandType{{query.OpQuoted, query.OpNumber}},
+				OnDuplicate:      map[string]string{"b": "2", "c": "c + 1"},
+				OnDuplicateTypes: map[string]query.OperandType{"b": query.OpQuoted, "c": query.OpExpression},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "INSERT with ON DUPLICATE KEY UPDATE without assignments fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES ('1') ON DUPLICATE KEY UPDATE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ON DUPLICATE KEY UPDATE: expected at least one field to update"),
+		},
+		{
+			Name:     "INSERT with ON DUPLICATE KEY UPDATE without value fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES ('1') ON DUPLICATE KEY UPDATE b =",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ON DUPLICATE KEY UPDATE: expected quoted value"),
+		},
+		{
+			Name:     "INSERT with ON DUPLICATE KEY UPDATE followed by WHERE fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES ('1') ON DUPLICATE KEY UPDATE b = '2' WHERE c = '3'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at ON DUPLICATE KEY UPDATE: expected ','"),
+		},
+		{
+			Name: "INSERT with RETURNING works",
+			SQL:  "INSERT INTO 'a' (b) VALUES ('1'), ('2') RETURNING id, b",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b"},
+				Inserts:     [][]string{{"1"}, {"2"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted}, {query.OpQuoted}},
+				Returning:   []string{"id", "b"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with RETURNING * works",
+			SQL:  "UPDATE 'a' SET b = '1' WHERE c = '2' RETURNING *",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "1"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				Returning: []string{"*"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "DELETE with LIMIT and RETURNING works",
+			SQL:  "DELETE FROM 'a' WHERE b = '1' LIMIT 1 returning b, c + 1",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "a",
+				Conditions: []query.Condition{
+					{Operand1: "b", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				Limit:     intPtr(1),
+				Returning: []string{"b", "c + 1"},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE with RETURNING but no WHERE fails",
+			SQL:      "UPDATE 'a' SET b = '1' RETURNING b",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: WHERE clause is mandatory for UPDATE & DELETE"),
+		},
+		{
+			Name:     "INSERT with empty RETURNING fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES ('1') RETURNING",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at RETURNING: expected field to return"),
+		},
+		{
+			Name:     "DELETE with RETURNING before WHERE fails",
+			SQL:      "DELETE FROM 'a' RETURNING b WHERE c = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at RETURNING: expected comma"),
+		},
+		{
+			Name:     "SELECT with RETURNING fails",
+			SQL:      "SELECT a FROM 'b' RETURNING a",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("expected WHERE"),
+		},
+		{
+			Name:     "Empty INSERT fails",
+			SQL:      "INSERT INTO",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name:     "INSERT with no rows to insert fails",
+			SQL:      "INSERT INTO 'a'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails",
+			SQL:      "INSERT INTO 'a' (",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails #2",
+			SQL:      "INSERT INTO 'a' (b",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails #3",
+			SQL:      "INSERT INTO 'a' (b)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete value section fails #4",
+			SQL:      "INSERT INTO 'a' (b) VALUES",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: need at least one row to insert"),
+		},
+		{
+			Name:     "INSERT with incomplete row fails",
+			SQL:      "INSERT INTO 'a' (b) VALUES (",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: row 1 has 0 values but 1 fields"),
+		},
+		{
+			Name: "INSERT works",
+			SQL:  "INSERT INTO 'a' (b) VALUES ('1')",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b"},
+				Inserts:     [][]string{{"1"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with unquoted table name works",
+			SQL:  "INSERT INTO users(b) VALUES ('1')",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "users",
+				Fields:      []string{"b"},
+				Inserts:     [][]string{{"1"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "INSERT with reserved word as table name fails",
+			SQL:      "INSERT INTO VALUES (b) VALUES ('1')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: expected table name"),
+		},
+		{
+			Name: "INSERT with NULL works",
+			SQL:  "INSERT INTO 'a' (b, c) VALUES (NULL, ''), ('', null)",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c"},
+				Inserts:     [][]string{{"", ""}, {"", ""}},
+				InsertTypes: [][]query.OperandType{{query.OpNull, query.OpQuoted}, {query.OpQuoted, query.OpNull}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with numbers works",
+			SQL:  "INSERT INTO 'a' (n, m, o) VALUES (42, -3.5, '42')",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"n", "m", "o"},
+				Inserts:     [][]string{{"42", "-3.5", "42"}},
+				InsertTypes: [][]query.OperandType{{query.OpNumber, query.OpNumber, query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "INSERT with identifier as value fails",
+			SQL:      "INSERT INTO 'a' (n) VALUES (b)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: expected quoted value, number or NULL"),
+		},
+		{
+			Name:     "INSERT * fails",
+			SQL:      "INSERT INTO 'a' (*) VALUES ('1')",
+			Expected: query.Query{},
 			Err:      fmt.Errorf("at INSERT INTO: expected at least one field to insert"),
 		},
 		{
-			Name: "INSERT with multiple fields works",
-			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' )",
-			Expected: query.Query{
-				Type:      query.Insert,
-				TableName: "a",
-				Fields:    []string{"b", "c", "d"},
-				Inserts:   [][]string{{"1", "2", "3"}},
-			},
-			Err: nil,
+			Name: "INSERT with multiple fields works",
+			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' )",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c", "d"},
+				Inserts:     [][]string{{"1", "2", "3"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted, query.OpQuoted, query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with multiple fields and multiple values works",
+			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' ),('4','5' ,'6' )",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c", "d"},
+				Inserts:     [][]string{{"1", "2", "3"}, {"4", "5", "6"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted, query.OpQuoted, query.OpQuoted}, {query.OpQuoted, query.OpQuoted, query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with SELECT works",
+			SQL:  "INSERT INTO 'a' (b,c) SELECT b,c FROM 'd' WHERE e = '1'",
+			Expected: query.Query{
+				Type:      query.Insert,
+				TableName: "a",
+				Fields:    []string{"b", "c"},
+				InsertSelect: &query.Query{
+					Type:      query.Select,
+					TableName: "d",
+					Fields:    []string{"b", "c"},
+					Conditions: []query.Condition{
+						{Operand1: "e", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "INSERT with invalid SELECT fails",
+			SQL:      "INSERT INTO 'a' (b) SELECT b FROM",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name:     "INSERT with SELECT followed by VALUES fails",
+			SQL:      "INSERT INTO 'a' (b) SELECT b FROM 'c' VALUES ('1')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("expected WHERE"),
+		},
+		{
+			Name: "CREATE TABLE works",
+			SQL:  "CREATE TABLE users (id INT, name VARCHAR, created_at TIMESTAMP)",
+			Expected: query.Query{
+				Type:      query.CreateTable,
+				TableName: "users",
+				Columns: []query.ColumnDef{
+					{Name: "id", Type: "INT"},
+					{Name: "name", Type: "VARCHAR"},
+					{Name: "created_at", Type: "TIMESTAMP"},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "CREATE TABLE with NOT NULL and PRIMARY KEY works",
+			SQL:  "create table public.users (id INT PRIMARY KEY NOT NULL, name VARCHAR not null)",
+			Expected: query.Query{
+				Type:      query.CreateTable,
+				Schema:    "public",
+				TableName: "users",
+				Columns: []query.ColumnDef{
+					{Name: "id", Type: "INT", NotNull: true, PrimaryKey: true},
+					{Name: "name", Type: "VARCHAR", NotNull: true},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "CREATE TABLE without columns fails",
+			SQL:      "CREATE TABLE users ()",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected column name"),
+		},
+		{
+			Name:     "CREATE TABLE without column type fails",
+			SQL:      "CREATE TABLE users (id, name VARCHAR)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected column type"),
+		},
+		{
+			Name:     "CREATE TABLE without closing parens fails",
+			SQL:      "CREATE TABLE users (id INT",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected closing parens"),
+		},
+		{
+			Name:     "CREATE TABLE with NOT but no NULL fails",
+			SQL:      "CREATE TABLE users (id INT NOT)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected NULL after NOT"),
+		},
+		{
+			Name:     "CREATE TABLE with tokens after closing parens fails",
+			SQL:      "CREATE TABLE users (id INT) WHERE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: unexpected token after closing parens"),
+		},
+		{
+			Name: "CREATE TABLE with type lengths and DEFAULT values works",
+			SQL:  "CREATE TABLE users (name VARCHAR(255) NOT NULL DEFAULT 'anon', balance DECIMAL (10, 2) default 0, deleted_at TIMESTAMP DEFAULT NULL)",
+			Expected: query.Query{
+				Type:      query.CreateTable,
+				TableName: "users",
+				Columns: []query.ColumnDef{
+					{Name: "name", Type: "VARCHAR", TypeParams: []int{255}, NotNull: true, Default: "anon", DefaultType: query.OpQuoted},
+					{Name: "balance", Type: "DECIMAL", TypeParams: []int{10, 2}, Default: "0", DefaultType: query.OpNumber},
+					{Name: "deleted_at", Type: "TIMESTAMP", DefaultType: query.OpNull},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "CREATE TABLE with DEFAULT but no value fails",
+			SQL:      "CREATE TABLE users (name VARCHAR DEFAULT)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected quoted value, number or NULL after DEFAULT"),
+		},
+		{
+			Name:     "CREATE TABLE with a field as DEFAULT fails",
+			SQL:      "CREATE TABLE users (name VARCHAR DEFAULT id)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected quoted value, number or NULL after DEFAULT"),
+		},
+		{
+			Name:     "CREATE TABLE with a non-numeric type length fails",
+			SQL:      "CREATE TABLE users (name VARCHAR(n))",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected non-negative integers as column type length"),
+		},
+		{
+			Name:     "CREATE TABLE with an unclosed type length fails",
+			SQL:      "CREATE TABLE users (name VARCHAR (255",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected closing parens after column type length"),
+		},
+		{
+			Name:     "CREATE TABLE with an unclosed type length right after the type fails",
+			SQL:      "CREATE TABLE users (name VARCHAR(",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CREATE TABLE: expected closing parens after column type length"),
+		},
+		{
+			Name: "SELECT with UNION works",
+			SQL:  "SELECT a, b FROM 'c' WHERE d = '1' UNION SELECT e, f FROM 'g'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "c",
+				Fields:    []string{"a", "b"},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+				Union: &query.Union{
+					Query: query.Query{
+						Type:      query.Select,
+						TableName: "g",
+						Fields:    []string{"e", "f"},
+					},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with chained UNION ALL works",
+			SQL:  "SELECT a FROM 'b' union all SELECT a FROM 'c' UNION SELECT a FROM 'd'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Union: &query.Union{
+					All: true,
+					Query: query.Query{
+						Type:      query.Select,
+						TableName: "c",
+						Fields:    []string{"a"},
+						Union: &query.Union{
+							Query: query.Query{
+								Type:      query.Select,
+								TableName: "d",
+								Fields:    []string{"a"},
+							},
+						},
+					},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with UNION but no SELECT after it fails",
+			SQL:      "SELECT a FROM 'b' UNION ALL",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UNION ALL: expected SELECT"),
+		},
+		{
+			Name:     "SELECT with UNION of a different number of fields fails",
+			SQL:      "SELECT a, b FROM 'c' UNION SELECT a FROM 'd'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at UNION: expected both SELECTs to have the same number of fields"),
+		},
+		{
+			Name:     "SELECT with UNION of an invalid SELECT fails",
+			SQL:      "SELECT a FROM 'b' UNION SELECT a FROM",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name: "SELECT with ? placeholders works",
+			SQL:  "SELECT a FROM 'b' WHERE c = ? AND d BETWEEN ? AND ? OR e LIKE ?",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "?", Operand2IsField: false, Operand2Type: query.OpPlaceholder},
+					{Operand1: "d", Operand1IsField: true, Operator: query.Between, Operand2: "?", Operand2Type: query.OpPlaceholder, Operand3: "?", Operand3Type: query.OpPlaceholder, OrWithNext: true},
+					{Operand1: "e", Operand1IsField: true, Operator: query.Like, Operand2: "?", Operand2Type: query.OpPlaceholder},
+				},
+				Params: []query.Param{{Index: 1, Placeholder: "?"}, {Index: 2, Placeholder: "?"}, {Index: 3, Placeholder: "?"}, {Index: 4, Placeholder: "?"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with $N placeholders works",
+			SQL:  "SELECT a FROM 'b' WHERE c = $2 AND d != $1",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "$2", Operand2IsField: false, Operand2Type: query.OpPlaceholder},
+					{Operand1: "d", Operand1IsField: true, Operator: query.Ne, Operand2: "$1", Operand2IsField: false, Operand2Type: query.OpPlaceholder},
+				},
+				Params: []query.Param{{Index: 2, Placeholder: "$2"}, {Index: 1, Placeholder: "$1"}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with $0 placeholder fails",
+			SQL:      "SELECT a FROM 'b' WHERE c = $0",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected quoted value"),
+		},
+		{
+			Name: "INSERT with placeholders works",
+			SQL:  "INSERT INTO 'a' (b, c) VALUES (?, ?), (?, '1')",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c"},
+				Inserts:     [][]string{{"?", "?"}, {"?", "1"}},
+				InsertTypes: [][]query.OperandType{{query.OpPlaceholder, query.OpPlaceholder}, {query.OpPlaceholder, query.OpQuoted}},
+				Params:      []query.Param{{Index: 1, Placeholder: "?"}, {Index: 2, Placeholder: "?"}, {Index: 3, Placeholder: "?"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE with placeholders works",
+			SQL:  "UPDATE 'a' SET b = $1 WHERE c = $2",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "$1"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpPlaceholder},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "$2", Operand2IsField: false, Operand2Type: query.OpPlaceholder},
+				},
+				Params: []query.Param{{Index: 1, Placeholder: "$1"}, {Index: 2, Placeholder: "$2"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with SELECT with placeholders numbers them along with the outer query's",
+			SQL:  "INSERT INTO 'a' (b) SELECT b FROM 'c' WHERE d = ? UNION SELECT b FROM 'e' WHERE f = ?",
+			Expected: query.Query{
+				Type:      query.Insert,
+				TableName: "a",
+				Fields:    []string{"b"},
+				InsertSelect: &query.Query{
+					Type:      query.Select,
+					TableName: "c",
+					Fields:    []string{"b"},
+					Conditions: []query.Condition{
+						{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "?", Operand2IsField: false, Operand2Type: query.OpPlaceholder},
+					},
+					Union: &query.Union{
+						Query: query.Query{
+							Type:      query.Select,
+							TableName: "e",
+							Fields:    []string{"b"},
+							Conditions: []query.Condition{
+								{Operand1: "f", Operand1IsField: true, Operator: query.Eq, Operand2: "?", Operand2IsField: false, Operand2Type: query.OpPlaceholder},
+							},
+						},
+					},
+				},
+				Params: []query.Param{{Index: 1, Placeholder: "?"}, {Index: 2, Placeholder: "?"}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT terminated by a semicolon works",
+			SQL:  "SELECT a FROM 'b';",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with unquoted table terminated by a semicolon after whitespace works",
+			SQL:  "SELECT a FROM b WHERE c = ';'   ;",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: ";", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with tokens after semicolon fails",
+			SQL:      "SELECT a FROM b; WHERE c = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("unexpected token after semicolon"),
+		},
+		{
+			Name:     "Incomplete query terminated by a semicolon fails",
+			SQL:      "SELECT a FROM;",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+		{
+			Name: "SELECT formatted across multiple lines works",
+			SQL:  "SELECT a,\n       b\nFROM 'c'\r\nWHERE d = '1'\n  AND e IS NOT NULL\nGROUP\n  BY a\nORDER BY b DESC\n",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "c",
+				Fields:    []string{"a", "b"},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+					{Operand1: "e", Operand1IsField: true, Operator: query.IsNotNull},
+				},
+				GroupBy: []string{"a"},
+				OrderBy: []query.OrderByField{{Field: "b", Direction: query.Desc}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE indented with tabs works",
+			SQL:  "UPDATE 'a'\n\tSET b = '1',\n\t\tc = c + 1\n\tWHERE\td = '2'",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"b": "1", "c": "c + 1"},
+				UpdateTypes: map[string]query.OperandType{"b": query.OpQuoted, "c": query.OpExpression},
+				Conditions: []query.Condition{
+					{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT formatted across multiple lines works",
+			SQL:  "INSERT INTO 'a'\n\t(b, c)\nVALUES\n\t('1', 2),\n\t('3', 4)",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c"},
+				Inserts:     [][]string{{"1", "2"}, {"3", "4"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted, query.OpNumber}, {query.OpQuoted, query.OpNumber}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "DROP TABLE works",
+			SQL:  "DROP TABLE users",
+			Expected: query.Query{
+				Type:      query.DropTable,
+				TableName: "users",
+			},
+			Err: nil,
+		},
+		{
+			Name: "DROP TABLE IF EXISTS works",
+			SQL:  "drop table if exists public.users",
+			Expected: query.Query{
+				Type:      query.DropTable,
+				Schema:    "public",
+				TableName: "users",
+				IfExists:  true,
+			},
+			Err: nil,
+		},
+		{
+			Name:     "DROP TABLE without table name fails",
+			SQL:      "DROP TABLE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at DROP TABLE: expected table name"),
+		},
+		{
+			Name:     "DROP TABLE IF EXISTS without table name fails",
+			SQL:      "DROP TABLE IF EXISTS",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at DROP TABLE: expected table name"),
+		},
+		{
+			Name:     "DROP TABLE with tokens after table name fails",
+			SQL:      "DROP TABLE users WHERE a = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at DROP TABLE: unexpected token after table name"),
+		},
+		{
+			Name: "TRUNCATE TABLE works",
+			SQL:  "TRUNCATE TABLE logs",
+			Expected: query.Query{
+				Type:      query.Truncate,
+				TableName: "logs",
+			},
+			Err: nil,
+		},
+		{
+			Name: "TRUNCATE without TABLE works",
+			SQL:  "truncate 'logs'",
+			Expected: query.Query{
+				Type:      query.Truncate,
+				TableName: "logs",
+			},
+			Err: nil,
+		},
+		{
+			Name:     "TRUNCATE without table name fails",
+			SQL:      "TRUNCATE TABLE",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at TRUNCATE: expected table name"),
+		},
+		{
+			Name:     "TRUNCATE with WHERE fails",
+			SQL:      "TRUNCATE logs WHERE a = '1'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at TRUNCATE: unexpected token after table name"),
+		},
+		{
+			Name: "DESCRIBE works",
+			SQL:  "DESCRIBE users",
+			Expected: query.Query{
+				Type:      query.Describe,
+				TableName: "users",
+			},
+			Err: nil,
+		},
+		{
+			Name: "DESC works",
+			SQL:  "desc public.users",
+			Expected: query.Query{
+				Type:      query.Describe,
+				Schema:    "public",
+				TableName: "users",
+			},
+			Err: nil,
+		},
+		{
+			Name:     "DESCRIBE without table name fails",
+			SQL:      "DESC",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at DESCRIBE: expected table name"),
+		},
+		{
+			Name:     "DESCRIBE with tokens after table name fails",
+			SQL:      "DESCRIBE users ORDER BY a DESC",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at DESCRIBE: unexpected token after table name"),
+		},
+		{
+			Name: "SHOW TABLES works",
+			SQL:  "show tables",
+			Expected: query.Query{
+				Type:     query.Show,
+				ShowKind: query.ShowTables,
+			},
+			Err: nil,
+		},
+		{
+			Name: "SHOW COLUMNS FROM works",
+			SQL:  "SHOW COLUMNS FROM 'users'",
+			Expected: query.Query{
+				Type:      query.Show,
+				ShowKind:  query.ShowColumns,
+				TableName: "users",
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SHOW with an unknown subcommand fails",
+			SQL:      "SHOW DATABASES",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SHOW: unknown subcommand DATABASES"),
+		},
+		{
+			Name:     "SHOW without subcommand fails",
+			SQL:      "SHOW",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SHOW: expected TABLES or COLUMNS"),
+		},
+		{
+			Name:     "SHOW COLUMNS without FROM fails",
+			SQL:      "SHOW COLUMNS 'users'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SHOW COLUMNS: expected FROM"),
+		},
+		{
+			Name:     "SHOW COLUMNS FROM without table name fails",
+			SQL:      "SHOW COLUMNS FROM",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SHOW COLUMNS: expected table name"),
+		},
+		{
+			Name:     "SHOW TABLES with tokens after it fails",
+			SQL:      "SHOW TABLES FROM 'users'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SHOW: unexpected token after SHOW TABLES"),
+		},
+		{
+			Name: "DELETE with USING works",
+			SQL:  "DELETE FROM 'a' USING 'b', s.c WHERE a.id = b.a_id AND b.c_id = c.id",
+			Expected: query.Query{
+				Type:      query.Delete,
+				TableName: "a",
+				Using:     []string{"b", "s.c"},
+				Conditions: []query.Condition{
+					{Operand1: "a.id", Operand1IsField: true, Operator: query.Eq, Operand2: "b.a_id", Operand2IsField: true, Operand2Type: query.OpField},
+					{Operand1: "b.c_id", Operand1IsField: true, Operator: query.Eq, Operand2: "c.id", Operand2IsField: true, Operand2Type: query.OpField},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "DELETE with USING without table fails",
+			SQL:      "DELETE FROM 'a' USING WHERE a.id = 1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at USING: expected table name"),
+		},
+		{
+			Name:     "DELETE with USING without anything after it fails",
+			SQL:      "DELETE FROM 'a' USING",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at USING: expected table name"),
+		},
+		{
+			Name:     "DELETE with USING without comma fails",
+			SQL:      "DELETE FROM 'a' USING 'b' 'c' WHERE a.id = b.id",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at USING: expected comma"),
+		},
+		{
+			Name: "UPDATE with FROM works",
+			SQL:  "UPDATE 'a' SET x = '1' FROM 'b', s.c WHERE a.id = b.a_id AND b.id = c.b_id",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "a",
+				Updates:     map[string]string{"x": "1"},
+				UpdateTypes: map[string]query.OperandType{"x": query.OpQuoted},
+				UpdateFrom:  []string{"b", "s.c"},
+				Conditions: []query.Condition{
+					{Operand1: "a.id", Operand1IsField: true, Operator: query.Eq, Operand2: "b.a_id", Operand2IsField: true, Operand2Type: query.OpField},
+					{Operand1: "b.id", Operand1IsField: true, Operator: query.Eq, Operand2: "c.b_id", Operand2IsField: true, Operand2Type: query.OpField},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "UPDATE with FROM without table fails",
+			SQL:      "UPDATE 'a' SET x = '1' FROM WHERE a.id = 1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at FROM: expected table name"),
+		},
+		{
+			Name:     "UPDATE with FROM without anything after it fails",
+			SQL:      "UPDATE 'a' SET x = '1' FROM",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at FROM: expected table name"),
+		},
+		{
+			Name: "CALL works",
+			SQL:  "CALL my_proc('arg1', 2, NULL, ?)",
+			Expected: query.Query{
+				Type:     query.Call,
+				ProcName: "my_proc",
+				Args:     []string{"arg1", "2", "", "?"},
+				ArgTypes: []query.OperandType{query.OpQuoted, query.OpNumber, query.OpNull, query.OpPlaceholder},
+				Params:   []query.Param{{Index: 1, Placeholder: "?"}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "CALL without arguments works",
+			SQL:      "call reporting.refresh ( )",
+			Expected: query.Query{Type: query.Call, Schema: "reporting", ProcName: "refresh"},
+			Err:      nil,
+		},
+		{
+			Name:     "CALL without procedure name fails",
+			SQL:      "CALL",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CALL: expected procedure name"),
+		},
+		{
+			Name:     "CALL without parens fails",
+			SQL:      "CALL refresh",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CALL: expected opening parens"),
+		},
+		{
+			Name:     "CALL with field as argument fails",
+			SQL:      "CALL my_proc(a)",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CALL: expected quoted value, number or NULL"),
+		},
+		{
+			Name:     "CALL with trailing comma fails",
+			SQL:      "CALL my_proc(1, )",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CALL: expected quoted value, number or NULL"),
+		},
+		{
+			Name:     "CALL without closing parens fails",
+			SQL:      "CALL my_proc(1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CALL: expected closing parens"),
+		},
+		{
+			Name:     "CALL with tokens after closing parens fails",
+			SQL:      "CALL my_proc(1) WHERE a = 1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at CALL: unexpected token after closing parens"),
+		},
+		{
+			Name:     "EXPLAIN works",
+			SQL:      "EXPLAIN SELECT a FROM 'b'",
+			Expected: query.Query{Type: query.Select, TableName: "b", Fields: []string{"a"}, Explain: true},
+			Err:      nil,
+		},
+		{
+			Name: "EXPLAIN ANALYZE works",
+			SQL:  "explain analyze DELETE FROM 'b' WHERE a = 1",
+			Expected: query.Query{
+				Type:       query.Delete,
+				TableName:  "b",
+				Conditions: []query.Condition{{Operand1: "a", Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2Type: query.OpNumber}},
+				Explain:    true,
+				Analyze:    true,
+			},
+			Err: nil,
+		},
+		{
+			Name:     "EXPLAIN without query fails",
+			SQL:      "EXPLAIN",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at EXPLAIN: expected query to explain"),
+		},
+		{
+			Name:     "EXPLAIN EXPLAIN fails",
+			SQL:      "EXPLAIN EXPLAIN SELECT a FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at EXPLAIN: expected query to explain"),
+		},
+		{
+			Name: "SELECT with quoted value that looks like reserved words works",
+			SQL:  "SELECT a FROM 'b' WHERE note = 'SELECT Something FROM where' AND c = 'MiXeD'",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "note", Operand1IsField: true, Operator: query.Eq, Operand2: "SELECT Something FROM where", Operand2Type: query.OpQuoted},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "MiXeD", Operand2Type: query.OpQuoted},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with quoted values that look like reserved words works",
+			SQL:  "INSERT INTO 'a' (b, c) VALUES ('Values', 'on Duplicate key update')",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c"},
+				Inserts:     [][]string{{"Values", "on Duplicate key update"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted, query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "INSERT with commas, parens and VALUES in quoted values works",
+			SQL:  "INSERT INTO 'a' (b, c, d) VALUES ('a,b)c', '(x', 'VALUES (1, 2)'), (')', '),(', 'it''s, (ok)')",
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c", "d"},
+				Inserts:     [][]string{{"a,b)c", "(x", "VALUES (1, 2)"}, {")", "),(", "it's, (ok)"}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted, query.OpQuoted, query.OpQuoted}, {query.OpQuoted, query.OpQuoted, query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "INSERT with a quoted value that looks like the end of the row fails if it's short of values",
+			SQL:      "INSERT INTO 'a' (b, c) VALUES ('), (')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: row 1 has 1 values but 2 fields"),
+		},
+		{
+			Name: "INSERT with backslashes in quoted values works",
+			SQL:  `INSERT INTO 'a' (b, c, d, e) VALUES ('C:\\', 'it\'s', 'a\\''b', '\\\'')`,
+			Expected: query.Query{
+				Type:        query.Insert,
+				TableName:   "a",
+				Fields:      []string{"b", "c", "d", "e"},
+				Inserts:     [][]string{{`C:\\`, `it\'s`, `a\\'b`, `\\\'`}},
+				InsertTypes: [][]query.OperandType{{query.OpQuoted, query.OpQuoted, query.OpQuoted, query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with a value ending in a backslash works",
+			SQL:  `SELECT a FROM 'b' WHERE c = 'd\\' AND e IN ('\\', 'f')`,
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: `d\\`, Operand2Type: query.OpQuoted},
+					{Operand1: "e", Operand1IsField: true, Operator: query.In, Operand2Type: query.OpList, Operand2List: []string{`\\`, "f"}, Operand2ListTypes: []query.OperandType{query.OpQuoted, query.OpQuoted}},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "INSERT with a value whose closing quote is escaped fails",
+			SQL:      `INSERT INTO 'a' (b) VALUES ('c\')`,
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: expected quoted value, number or NULL"),
+		},
+		{
+			Name:     "query with quoted query type fails",
+			SQL:      "'select' a FROM 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("invalid query type"),
+		},
+		{
+			Name:     "SELECT with quoted FROM fails",
+			SQL:      "SELECT a 'from' 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at SELECT: expected comma or FROM"),
+		},
+		{
+			Name:     "INSERT with quoted VALUES fails",
+			SQL:      "INSERT INTO 'a' (b) 'values' ('1')",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at INSERT INTO: expected 'VALUES'"),
+		},
+		{
+			Name: "SELECT keeps the casing of identifiers",
+			SQL:  "select MyCol as MyAlias, Count(OtherCol) from MySchema.MyTable as MyT join OtherTable on MyT.Id = OtherTable.MyId where MyCol = OtherCol group by MyCol order by MyCol",
+			Expected: query.Query{
+				Type:       query.Select,
+				Schema:     "MySchema",
+				TableName:  "MyTable",
+				TableAlias: "MyT",
+				Joins: []query.Join{{Type: query.Inner, TableName: "OtherTable", On: []query.Condition{
+					{Operand1: "MyT.Id", Operand1IsField: true, Operator: query.Eq, Operand2: "OtherTable.MyId", Operand2IsField: true, Operand2Type: query.OpField},
+				}}},
+				Conditions: []query.Condition{{Operand1: "MyCol", Operand1IsField: true, Operator: query.Eq, Operand2: "OtherCol", Operand2IsField: true, Operand2Type: query.OpField}},
+				Fields:     []string{"MyCol", "Count(OtherCol)"},
+				Aliases:    map[string]string{"MyCol": "MyAlias"},
+				GroupBy:    []string{"MyCol"},
+				OrderBy:    []query.OrderByField{{Field: "MyCol", Direction: query.Asc}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "UPDATE keeps the casing of identifiers",
+			SQL:  "update MyTable set MyCol = 1 where MyId = 2 returning MyCol",
+			Expected: query.Query{
+				Type:        query.Update,
+				TableName:   "MyTable",
+				Updates:     map[string]string{"MyCol": "1"},
+				UpdateTypes: map[string]query.OperandType{"MyCol": query.OpNumber},
+				Conditions:  []query.Condition{{Operand1: "MyId", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2Type: query.OpNumber}},
+				Returning:   []string{"MyCol"},
+			},
+			Err: nil,
+		},
+		{
+			Name: "CREATE TABLE keeps the casing of identifiers and types",
+			SQL:  "create table MyTable (MyCol VarChar(10))",
+			Expected: query.Query{
+				Type:      query.CreateTable,
+				TableName: "MyTable",
+				Columns:   []query.ColumnDef{{Name: "MyCol", Type: "VarChar", TypeParams: []int{10}}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with negative number works",
+			SQL:  "SELECT a FROM 'b' WHERE price = -5",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "b",
+				Fields:     []string{"a"},
+				Conditions: []query.Condition{{Operand1: "price", Operand1IsField: true, Operator: query.Eq, Operand2: "-5", Operand2Type: query.OpNumber}},
+			},
+			Err: nil,
+		},
+		{
+			Name: "SELECT with hyphenated identifiers works",
+			SQL:  "SELECT a FROM my-table WHERE a-b = -5 AND c = d-e",
+			Expected: query.Query{
+				Type:      query.Select,
+				TableName: "my-table",
+				Fields:    []string{"a"},
+				Conditions: []query.Condition{
+					{Operand1: "a-b", Operand1IsField: true, Operator: query.Eq, Operand2: "-5", Operand2Type: query.OpNumber},
+					{Operand1: "c", Operand1IsField: true, Operator: query.Eq, Operand2: "d-e", Operand2IsField: true, Operand2Type: query.OpField},
+				},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with leading hyphen in identifier fails",
+			SQL:      "SELECT a FROM 'b' WHERE -c = 1",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("at WHERE: expected field"),
+		},
+		{
+			Name: "SELECT with comments works",
+			SQL:  "-- Leading comment\n/* block */ SELECT a, /* multi\nline */ b FROM 'c' -- trailing comment\nWHERE d = 'e -- not a comment' /* trailing */",
+			Expected: query.Query{
+				Type:       query.Select,
+				TableName:  "c",
+				Fields:     []string{"a", "b"},
+				Conditions: []query.Condition{{Operand1: "d", Operand1IsField: true, Operator: query.Eq, Operand2: "e -- not a comment", Operand2Type: query.OpQuoted}},
+			},
+			Err: nil,
+		},
+		{
+			Name:     "SELECT with unterminated block comment fails",
+			SQL:      "SELECT a FROM 'b' /* unterminated",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("unterminated comment"),
+		},
+		{
+			Name:     "SELECT with unterminated block comment hiding the rest of the query fails",
+			SQL:      "SELECT a FROM 'b' WHERE /* c = 'd'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("unterminated comment"),
+		},
+		{
+			Name:     "SELECT with only comments after FROM fails",
+			SQL:      "SELECT a FROM -- 'b'",
+			Expected: query.Query{},
+			Err:      fmt.Errorf("table name cannot be empty"),
+		},
+	}
+
+	output := output{Types: query.TypeString, Operators: query.OperatorString, OperandTypes: query.OperandTypeString, Directions: query.DirectionString, JoinTypes: query.JoinTypeString, NullsOrders: query.NullsOrderString, ShowKinds: query.ShowKindString}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			actual, err := ParseMany([]string{tc.SQL})
+			if tc.Err != nil && err == nil {
+				t.Errorf("Error should have been %v", tc.Err)
+			}
+			if tc.Err == nil && err != nil {
+				t.Errorf("Error should have been nil but was %v", err)
+			}
+			if tc.Err != nil && err != nil {
+				require.EqualError(t, err, tc.Err.Error(), "Unexpected error")
+			}
+			if len(actual) > 0 {
+				// Most cases are about other things than the structured form of fields, so it's only checked if expected
+				if tc.Expected.FieldExprs == nil {
+					removeFieldExprs(&actual[0])
+				}
+				if tc.Expected.UpdatePairs == nil {
+					actual[0].UpdatePairs = nil
+				}
+				removeConditionPos(&actual[0]) // Positions are checked in TestConditionPos
+				require.Equal(t, tc.Expected, actual[0], "Query didn't match expectation")
+			}
+			if tc.Err != nil {
+				output.ErrorExamples = append(output.ErrorExamples, tc)
+			} else {
+				output.NoErrorExamples = append(output.NoErrorExamples, tc)
+			}
+		})
+	}
+	createReadme(output)
+}
+
+func TestOrGroups(t *testing.T) {
+	q, err := Parse("SELECT a FROM 'b' WHERE a = '1' AND b = '2' OR c = '3' AND d = '4' OR e = '5'")
+	require.NoError(t, err)
+	groups := query.OrGroups(q.Conditions)
+	require.Len(t, groups, 3)
+	require.Equal(t, []string{"a", "b"}, []string{groups[0][0].Operand1, groups[0][1].Operand1})
+	require.Equal(t, []string{"c", "d"}, []string{groups[1][0].Operand1, groups[1][1].Operand1})
+	require.Equal(t, "e", groups[2][0].Operand1)
+}
+
+func TestParseScript(t *testing.T) {
+	qs, err := ParseScript("SELECT a FROM 'b;c' WHERE d = ';'; ;\n DELETE FROM e WHERE f = 'it\\';s' ;UPDATE g SET h = 1 WHERE i = 2;")
+	require.NoError(t, err)
+	require.Len(t, qs, 3)
+	require.Equal(t, "b;c", qs[0].TableName)
+	require.Equal(t, ";", qs[0].Conditions[0].Operand2)
+	require.Equal(t, "it\\';s", qs[1].Conditions[0].Operand2)
+	require.Equal(t, query.Update, qs[2].Type)
+
+	qs, err = ParseScript("SELECT a FROM b; -- not; a statement\nSELECT c /* nor; this */ FROM d; -- trailing comment")
+	require.NoError(t, err)
+	require.Len(t, qs, 2)
+	require.Equal(t, "d", qs[1].TableName)
+
+	qs, err = ParseScript("SELECT a FROM b; /* SELECT c FROM d;")
+	require.EqualError(t, err, "unterminated comment")
+	require.Len(t, qs, 1)
+
+	qs, err = ParseScript("SELECT a FROM b; SELECT FROM c; SELECT d FROM e")
+	require.EqualError(t, err, "at SELECT: expected field to SELECT")
+	require.Len(t, qs, 1)
+}
+
+func TestParseReader(t *testing.T) {
+	script := "SELECT a FROM 'b;c' WHERE d = 'it\\';s' -- e; f\n; /* g; */ ;\nDELETE FROM h WHERE i = 'it''s;'\n; UPDATE j SET k = 1 WHERE l = 2 /* m */; SELECT n FROM o WHERE p LIKE 'q\\_' ESCAPE '\\'; SELECT r FROM s"
+	// One byte at a time, statements, quoted strings and comments are split across every possible boundary
+	qs, err := ParseReader(iotest.OneByteReader(strings.NewReader(script)))
+	require.NoError(t, err)
+	require.Len(t, qs, 5)
+	require.Equal(t, "b;c", qs[0].TableName)
+	require.Equal(t, "it\\';s", qs[0].Conditions[0].Operand2)
+	require.Equal(t, "it's;", qs[1].Conditions[0].Operand2)
+	require.Equal(t, query.Update, qs[2].Type)
+	require.Equal(t, "\\", qs[3].Conditions[0].Escape)
+	require.Equal(t, "s", qs[4].TableName)
+
+	expected, err := ParseScript(script)
+	require.NoError(t, err)
+	require.Equal(t, expected, qs)
+
+	qs, err = ParseReader(strings.NewReader("SELECT a FROM b; SELECT FROM c; SELECT d FROM e"))
+	require.EqualError(t, err, "at SELECT: expected field to SELECT")
+	require.Len(t, qs, 1)
+
+	_, err = ParseReader(iotest.TimeoutReader(strings.NewReader("SELECT a FROM b")))
+	require.Equal(t, iotest.ErrTimeout, err)
+}
+
+func TestParseReaderFunc(t *testing.T) {
+	var tableNames []string
+	errStop := errors.New("stop")
+	err := ParseReaderFunc(strings.NewReader("SELECT a FROM b; SELECT a FROM c; SELECT a FROM d"), func(q query.Query) error {
+		tableNames = append(tableNames, q.TableName)
+		if q.TableName == "c" {
+			return errStop
+		}
+		return nil
+	})
+	require.Equal(t, errStop, err)
+	require.Equal(t, []string{"b", "c"}, tableNames)
+
+	// Each statement is parsed as soon as it's read, without waiting for more
+	r := &statementReader{statements: []string{"SELECT a FROM b;", " SELECT a FROM c;", " SELECT a FROM d"}}
+	tableNames = nil
+	err = ParseReaderFunc(r, func(q query.Query) error {
+		tableNames = append(tableNames, q.TableName)
+		require.Equal(t, q.TableName == "d", r.atEOF, "Unexpected EOF state when parsing %s", q.TableName)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c", "d"}, tableNames)
+}
+
+// statementReader returns a statement per Read, like a slow reader that's yet to receive the rest would
+type statementReader struct {
+	statements []string
+	atEOF      bool
+}
+
+func (r *statementReader) Read(b []byte) (int, error) {
+	if len(r.statements) == 0 {
+		r.atEOF = true
+		return 0, io.EOF
+	}
+	n := copy(b, r.statements[0])
+	r.statements = r.statements[1:]
+	return n, nil
+}
+
+func TestParseAll(t *testing.T) {
+	qs, errs := ParseAll([]string{"SELECT a FROM b", "SELECT FROM c", "DELETE FROM d WHERE e = 1", "UPDATE f"})
+	require.Len(t, qs, 4)
+	require.Len(t, errs, 4)
+	require.NoError(t, errs[0])
+	require.Equal(t, "b", qs[0].TableName)
+	require.EqualError(t, errs[1], "at SELECT: expected field to SELECT")
+	require.Equal(t, query.Query{}, qs[1])
+	require.NoError(t, errs[2])
+	require.Equal(t, "d", qs[2].TableName)
+	require.EqualError(t, errs[3], "at WHERE: WHERE clause is mandatory for UPDATE & DELETE")
+	require.Equal(t, query.Query{}, qs[3])
+}
+
+func TestParseContext(t *testing.T) {
+	q, err := ParseContext(context.Background(), "SELECT a FROM 'b'")
+	require.NoError(t, err)
+	require.Equal(t, "b", q.TableName)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	q, err = ParseContext(ctx, "SELECT a FROM 'b'")
+	require.True(t, errors.Is(err, context.Canceled), "Expected context.Canceled, but got %v", err)
+	require.Equal(t, query.Query{}, q)
+}
+
+func TestParserDoesNotSpinOnUnconsumedTokens(t *testing.T) {
+	// No step handles this one, so nothing consumes the SQL
+	p := &parser{ctx: context.Background(), sql: "SELECT a FROM 'b'", step: step(-1)}
+	_, err := p.parse()
+	require.EqualError(t, err, "unexpected token")
+	require.Equal(t, 0, p.i)
+}
+
+func TestErrorWithPos(t *testing.T) {
+	ts := []struct {
+		SQL    string
+		Pos    int
+		Line   int
+		Column int
+		Output string
+	}{
+		{
+			SQL:    "SELECT FROM 'b'",
+			Pos:    7,
+			Line:   1,
+			Column: 8,
+			Output: "SELECT FROM 'b'\n       ^\n",
+		},
+		{
+			SQL:    "\n\n  SELECT FROM 'b'",
+			Pos:    11,
+			Line:   3,
+			Column: 10,
+			Output: "  SELECT FROM 'b'\n         ^\n",
+		},
+		{
+			SQL:    "SELECT a FROM 'b' WHERE c = 'multi\n\tline' AND d ! 'e'\nORDER BY a",
+			Pos:    48,
+			Line:   2,
+			Column: 14,
+			Output: "\tline' AND d ! 'e'\n\t            ^\n",
+		},
+		{
+			SQL:    "SELECT a FROM 'b' WHERE c = 1e",
+			Pos:    28,
+			Line:   1,
+			Column: 29,
+			Output: "SELECT a FROM 'b' WHERE c = 1e\n                            ^\n",
+		},
+		{
+			SQL:    "SELECT a FROM 'b' ORDER BY a, 0",
+			Pos:    30,
+			Line:   1,
+			Column: 31,
+			Output: "SELECT a FROM 'b' ORDER BY a, 0\n                              ^\n",
+		},
+		{
+			SQL:    "-- comment\nSELECT /* comment */ FROM 'b'",
+			Pos:    32,
+			Line:   2,
+			Column: 22,
+			Output: "SELECT /* comment */ FROM 'b'\n                     ^\n",
+		},
+		{
+			SQL:    "INSERT INTO 'a' (b, c) VALUES ('1', '2'), ('3')",
+			Pos:    46,
+			Line:   1,
+			Column: 47,
+			Output: "INSERT INTO 'a' (b, c) VALUES ('1', '2'), ('3')\n                                              ^\n",
+		},
+		{
+			SQL:    "INSERT INTO 'a' (b) VALUES ('1', '2'), ('3')",
+			Pos:    36,
+			Line:   1,
+			Column: 37,
+			Output: "INSERT INTO 'a' (b) VALUES ('1', '2'), ('3')\n                                    ^\n",
+		},
+		{
+			SQL:    "SELECT a AS x, b AS x FROM 'b'",
+			Pos:    20,
+			Line:   1,
+			Column: 21,
+			Output: "SELECT a AS x, b AS x FROM 'b'\n                    ^\n",
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			_, err := Parse(tc.SQL)
+			posErr, ok := err.(*ErrorWithPos)
+			require.True(t, ok, "Expected an *ErrorWithPos, but got %v", err)
+			require.Equal(t, tc.Pos, posErr.Pos())
+			require.Equal(t, tc.Line, posErr.Line())
+			require.Equal(t, tc.Column, posErr.Column())
+			var buf bytes.Buffer
+			posErr.PrintPosError(&buf)
+			require.True(t, strings.HasPrefix(buf.String(), tc.Output), "Unexpected output %q", buf.String())
+		})
+	}
+}
+
+func TestPrintPosErrorWritesEverythingToWriter(t *testing.T) {
+	_, err := Parse("SELECT a FROM 'b' WHERE")
+	posErr, ok := err.(*ErrorWithPos)
+	require.True(t, ok, "Expected an *ErrorWithPos, but got %v", err)
+	var buf bytes.Buffer
+	posErr.PrintPosError(&buf)
+	require.Equal(t, "SELECT a FROM 'b' WHERE\n                       ^\nat WHERE: empty WHERE clause\n", buf.String())
+}
+
+func TestParseErrorPrintsNothing(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	stdout := os.Stdout
+	os.Stdout = w
+	_, parseErr := Parse("SELECT a FROM 'b' WHERE")
+	os.Stdout = stdout
+	require.NoError(t, w.Close())
+	printed, err := ioutil.ReadAll(r)
+	require.NoError(t, err)
+	require.Error(t, parseErr)
+	require.Empty(t, string(printed))
+}
+
+// removeConditionPos zeroes the position of every condition, so that queries parsed from SQL that's written
+// differently can be compared
+func removeConditionPos(q *query.Query) {
+	q.Walk(func(node interface{}) bool {
+		switch n := node.(type) {
+		case *query.Condition:
+			n.Pos = 0
+		case *query.Query:
+			removeWhereExprPos(n.WhereExpr)
+		}
+		return true
+	})
+}
+
+func removeWhereExprPos(e *query.WhereExpr) {
+	if e == nil {
+		return
+	}
+	if e.Condition != nil {
+		e.Condition.Pos = 0
+	}
+	for i := range e.Children {
+		removeWhereExprPos(&e.Children[i])
+	}
+}
+
+func removeFieldExprs(q *query.Query) {
+	q.Walk(func(node interface{}) bool {
+		if n, ok := node.(*query.Query); ok {
+			n.FieldExprs = nil
+		}
+		return true
+	})
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func createReadme(out output) {
+	content, err := ioutil.ReadFile("README.template")
+	if err != nil {
+		log.Fatal(err)
+	}
+	t := template.Must(template.New("").Parse(string(content)))
+	f, err := os.Create("README.md")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := t.Execute(f, out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestString(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected string
+	}{
+		{SQL: "select a as z, b FROM 'b'", Expected: "SELECT a AS z, b FROM 'b'"},
+		{SQL: "select distinct a FROM 'b'", Expected: "SELECT DISTINCT a FROM 'b'"},
+		{SQL: "SELECT a FROM 'b' c WHERE a = '1'", Expected: "SELECT a FROM 'b' AS c WHERE a = '1'"},
+		{
+			SQL:      "SELECT a FROM 'b' WHERE a = '1' AND b != c OR d > -2.5 AND e IN ('x', 'y') OR f NOT IN (1,2)",
+			Expected: "SELECT a FROM 'b' WHERE a = '1' AND b != c OR d > -2.5 AND e IN ('x', 'y') OR f NOT IN (1, 2)",
+		},
+		{
+			SQL:      "SELECT a FROM 'b' WHERE a BETWEEN 1 AND '5' AND b LIKE '%x_' AND c NOT LIKE 'y' AND d IS NULL AND e IS NOT NULL",
+			Expected: "SELECT a FROM 'b' WHERE a BETWEEN 1 AND '5' AND b LIKE '%x_' AND c NOT LIKE 'y' AND d IS NULL AND e IS NOT NULL",
 		},
 		{
-			Name: "INSERT with multiple fields and multiple values works",
-			SQL:  "INSERT INTO 'a' (b,c,    d) VALUES ('1','2' ,  '3' ),('4','5' ,'6' )",
-			Expected: query.Query{
-				Type:      query.Insert,
-				TableName: "a",
-				Fields:    []string{"b", "c", "d"},
-				Inserts:   [][]string{{"1", "2", "3"}, {"4", "5", "6"}},
-			},
-			Err: nil,
+			SQL:      "SELECT dept, count(id) FROM 'emp' GROUP BY dept HAVING count(id) >= '5' ORDER BY dept DESC, b ASC LIMIT 20, 10",
+			Expected: "SELECT dept, count(id) FROM 'emp' GROUP BY dept HAVING count(id) >= '5' ORDER BY dept DESC, b LIMIT 10 OFFSET 20",
 		},
+		{SQL: "INSERT INTO 'a' (b,c) VALUES ('1','2'),('3', 'it\\'s')", Expected: "INSERT INTO 'a' (b, c) VALUES ('1', '2'), ('3', 'it\\'s')"},
+		{SQL: `INSERT INTO 'a' (b, c) VALUES ('C:\\', 'a\\''b')`, Expected: `INSERT INTO 'a' (b, c) VALUES ('C:\\', 'a\\''b')`},
+		{SQL: `SELECT concat(a, 'b\\'), c FROM d WHERE e = 'f\\'`, Expected: `SELECT concat(a, 'b\\'), c FROM 'd' WHERE e = 'f\\'`},
+		{SQL: "SELECT a FROM b WHERE c = 'd' collate utf8_bin AND e BETWEEN 'f' AND 'g' COLLATE utf8_bin", Expected: "SELECT a FROM 'b' WHERE c = 'd' COLLATE utf8_bin AND e BETWEEN 'f' AND 'g' COLLATE utf8_bin"},
+		{SQL: "INSERT INTO a (b, c) VALUES ('a,b)c', 'VALUES (1, 2)'), (')', '),(')", Expected: "INSERT INTO 'a' (b, c) VALUES ('a,b)c', 'VALUES (1, 2)'), (')', '),(')"},
+		{SQL: "UPDATE orders SET a = 1 WHERE orders.total > orders.paid", Expected: "UPDATE 'orders' SET a = 1 WHERE orders.total > orders.paid"},
+		{SQL: "DELETE FROM a WHERE NOT EXISTS (SELECT 1 FROM b WHERE b.a_id = a.id AND c = ?) OR (exists (select 1 from d))", Expected: "DELETE FROM 'a' WHERE NOT EXISTS (SELECT 1 FROM 'b' WHERE b.a_id = a.id AND c = ?) OR (EXISTS (SELECT 1 FROM 'd'))"},
+		{SQL: "SELECT a FROM b WHERE c >= (SELECT avg(c) FROM b) AND d NOT IN (SELECT d FROM e WHERE f = $1)", Expected: "SELECT a FROM 'b' WHERE c >= (SELECT avg(c) FROM 'b') AND d NOT IN (SELECT d FROM 'e' WHERE f = $1)"},
+		{SQL: "SELECT t.a, count(*) FROM (SELECT a FROM (SELECT a, b FROM c) AS u WHERE b = 1) t GROUP BY t.a", Expected: "SELECT t.a, count(*) FROM (SELECT a FROM (SELECT a, b FROM 'c') AS u WHERE b = 1) AS t GROUP BY t.a"},
+		{SQL: "UPDATE 'a' SET c = 'bye', b = 'hello' WHERE a <= '1'", Expected: "UPDATE 'a' SET c = 'bye', b = 'hello' WHERE a <= '1'"},
+		{SQL: "UPDATE 'a' SET b = 'it''s' WHERE a IN ('''', 'x')", Expected: "UPDATE 'a' SET b = 'it''s' WHERE a IN ('''', 'x')"},
+		{SQL: "SELECT a FROM b WHERE c IN (1,'2', -3.5) AND d NOT IN ('4')", Expected: "SELECT a FROM 'b' WHERE c IN (1, '2', -3.5) AND d NOT IN ('4')"},
+		{SQL: "DELETE FROM 'a' WHERE b < '1'", Expected: "DELETE FROM 'a' WHERE b < '1'"},
+		{SQL: "INSERT INTO 'a' (b, c) VALUES (null, '')", Expected: "INSERT INTO 'a' (b, c) VALUES (NULL, '')"},
+		{SQL: "INSERT INTO 'a' (b, c) VALUES (1, '1')", Expected: "INSERT INTO 'a' (b, c) VALUES (1, '1')"},
+		{SQL: "INSERT INTO a (b, c) SELECT b, c FROM d WHERE e = 1", Expected: "INSERT INTO 'a' (b, c) SELECT b, c FROM 'd' WHERE e = 1"},
+		{SQL: "SELECT a FROM b WHERE c = 1 UNION ALL SELECT a FROM d UNION SELECT a FROM e", Expected: "SELECT a FROM 'b' WHERE c = 1 UNION ALL SELECT a FROM 'd' UNION SELECT a FROM 'e'"},
+		{SQL: "SELECT a FROM b WHERE c = ? AND d LIKE $2", Expected: "SELECT a FROM 'b' WHERE c = ? AND d LIKE $2"},
+		{SQL: "SELECT a FROM b WHERE c LIKE 'd!%' escape '!' AND e NOT LIKE 'f\\_' ESCAPE '\\\\'", Expected: "SELECT a FROM 'b' WHERE c LIKE 'd!%' ESCAPE '!' AND e NOT LIKE 'f\\_' ESCAPE '\\'"},
+		{SQL: "SELECT a FROM b WHERE c LIKE 'd\\_' ESCAPE '\\' AND e LIKE 'f' ESCAPE ''''", Expected: "SELECT a FROM 'b' WHERE c LIKE 'd\\_' ESCAPE '\\' AND e LIKE 'f' ESCAPE ''''"},
+		{SQL: "DELETE FROM a WHERE b = 1 LIMIT 10", Expected: "DELETE FROM 'a' WHERE b = 1 LIMIT 10"},
+		{SQL: "REPLACE INTO a (b) VALUES (1)", Expected: "REPLACE INTO 'a' (b) VALUES (1)"},
+		{SQL: "INSERT INTO a (b, c) VALUES (1, 2) ON DUPLICATE KEY UPDATE c = 3, b = b + 1", Expected: "INSERT INTO 'a' (b, c) VALUES (1, 2) ON DUPLICATE KEY UPDATE b = b + 1, c = 3"},
+		{SQL: "DELETE FROM a WHERE b = 1 RETURNING b, c", Expected: "DELETE FROM 'a' WHERE b = 1 RETURNING b, c"},
+		{SQL: "SELECT a, b FROM c ORDER BY 2 DESC, a", Expected: "SELECT a, b FROM 'c' ORDER BY 2 DESC, a"},
+		{SQL: "SELECT a FROM b ORDER BY a DESC NULLS FIRST, 1 nulls last", Expected: "SELECT a FROM 'b' ORDER BY a DESC NULLS FIRST, 1 NULLS LAST"},
+		{SQL: "CREATE TABLE users (id INT NOT NULL PRIMARY KEY, name VARCHAR)", Expected: "CREATE TABLE 'users' (id INT NOT NULL PRIMARY KEY, name VARCHAR)"},
+		{SQL: "CREATE TABLE users (name VARCHAR(255) NOT NULL DEFAULT 'anon', balance DECIMAL(10,2) DEFAULT 0)", Expected: "CREATE TABLE 'users' (name VARCHAR(255) NOT NULL DEFAULT 'anon', balance DECIMAL(10, 2) DEFAULT 0)"},
+		{SQL: "DROP TABLE IF EXISTS public.users", Expected: "DROP TABLE IF EXISTS public.users"},
+		{SQL: "DELETE FROM a WHERE (b = 1 OR c = 2) AND (d = 3 OR (e = 4)) OR f = 5", Expected: "DELETE FROM 'a' WHERE (b = 1 OR c = 2) AND (d = 3 OR (e = 4)) OR f = 5"},
+		{SQL: "SELECT a FROM b WHERE NOT c = 1 AND NOT (d = 2 OR NOT e LIKE 'x%')", Expected: "SELECT a FROM 'b' WHERE NOT c = 1 AND NOT (d = 2 OR NOT e LIKE 'x%')"},
+		{SQL: "SELECT a, 'b', 1, NULL, f(g(c), 'd') AS e FROM h", Expected: "SELECT a, 'b', 1, NULL, f(g(c), 'd') AS e FROM 'h'"},
+		{SQL: "SELECT a * 2 AS b, c - d FROM e", Expected: "SELECT a * 2 AS b, c - d FROM 'e'"},
+		{SQL: "TRUNCATE logs", Expected: "TRUNCATE TABLE 'logs'"},
+		{SQL: "DESC logs", Expected: "DESCRIBE 'logs'"},
+		{SQL: "SHOW TABLES", Expected: "SHOW TABLES"},
+		{SQL: "show columns from public.logs", Expected: "SHOW COLUMNS FROM public.logs"},
+		{SQL: "DELETE FROM a USING b,s.c WHERE a.id = b.id", Expected: "DELETE FROM 'a' USING 'b', s.c WHERE a.id = b.id"},
+		{SQL: "UPDATE a SET x = 1 FROM b WHERE a.id = b.id", Expected: "UPDATE 'a' SET x = 1 FROM 'b' WHERE a.id = b.id"},
+		{SQL: "call my_proc('it''s',2,NULL, $1)", Expected: "CALL my_proc('it''s', 2, NULL, $1)"},
+		{SQL: "CALL reporting.refresh()", Expected: "CALL reporting.refresh()"},
+		{SQL: "explain SELECT a FROM 'b'", Expected: "EXPLAIN SELECT a FROM 'b'"},
+		{SQL: "EXPLAIN  ANALYZE UPDATE 'b' SET a = 1 WHERE c = 2", Expected: "EXPLAIN ANALYZE UPDATE 'b' SET a = 1 WHERE c = 2"},
+		{SQL: "UPDATE 'a' SET c = 1, b = 2, c = 3 WHERE d = '1'", Expected: "UPDATE 'a' SET c = 1, b = 2, c = 3 WHERE d = '1'"},
+		{SQL: "UPDATE 'a' SET b = NULL WHERE c = NULL", Expected: "UPDATE 'a' SET b = NULL WHERE c = NULL"},
+		{SQL: "UPDATE 'a' SET b = b * 2, c = d WHERE c = '1'", Expected: "UPDATE 'a' SET b = b * 2, c = d WHERE c = '1'"},
+		{SQL: "UPDATE 'a' SET b = -3, c = '-3' WHERE c = '1'", Expected: "UPDATE 'a' SET b = -3, c = '-3' WHERE c = '1'"},
+		{SQL: "DELETE FROM public.a WHERE a.b < '1'", Expected: "DELETE FROM public.a WHERE a.b < '1'"},
+		{SQL: "SELECT a FROM x JOIN public.y AS z ON x.id = z.x_id", Expected: "SELECT a FROM 'x' INNER JOIN public.y AS z ON x.id = z.x_id"},
+		{SQL: "SELECT a FROM x JOIN y ON x.a = y.a AND x.b = y.b OR x.c = 1 WHERE d = 2", Expected: "SELECT a FROM 'x' INNER JOIN 'y' ON x.a = y.a AND x.b = y.b OR x.c = 1 WHERE d = 2"},
+		{SQL: "SELECT a FROM x LEFT OUTER JOIN y ON x.id = y.x_id FULL JOIN z ON x.id = z.x_id", Expected: "SELECT a FROM 'x' LEFT JOIN 'y' ON x.id = y.x_id FULL OUTER JOIN 'z' ON x.id = z.x_id"},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, q.String())
+			reparsed, err := Parse(q.String())
+			require.NoError(t, err)
+			removeConditionPos(&q)
+			removeConditionPos(&reparsed)
+			require.Equal(t, q, reparsed, "Query didn't survive the round trip")
+		})
 	}
+}
 
-	output := output{Types: query.TypeString, Operators: query.OperatorString}
+func TestStringOfBuiltQueries(t *testing.T) {
+	ts := []struct {
+		Name     string
+		Query    query.Query
+		Expected string
+	}{
+		{
+			Name:     "INSERT without InsertTypes",
+			Query:    query.Query{Type: query.Insert, TableName: "a", Fields: []string{"b", "c"}, Inserts: [][]string{{"1", "x"}, {"2", "y"}}},
+			Expected: "INSERT INTO 'a' (b, c) VALUES ('1', 'x'), ('2', 'y')",
+		},
+		{
+			Name: "INSERT with InsertTypes for fewer values",
+			Query: query.Query{
+				Type: query.Insert, TableName: "a", Fields: []string{"b", "c"}, Inserts: [][]string{{"1", "x"}, {"2", "y"}},
+				InsertTypes: [][]query.OperandType{{query.OpNumber}},
+			},
+			Expected: "INSERT INTO 'a' (b, c) VALUES (1, 'x'), ('2', 'y')",
+		},
+		{
+			Name:     "CALL without ArgTypes",
+			Query:    query.Query{Type: query.Call, ProcName: "a", Args: []string{"1", "x"}},
+			Expected: "CALL a('1', 'x')",
+		},
+	}
 	for _, tc := range ts {
 		t.Run(tc.Name, func(t *testing.T) {
-			actual, err := ParseMany([]string{tc.SQL})
-			if tc.Err != nil && err == nil {
-				t.Errorf("Error should have been %v", tc.Err)
-			}
-			if tc.Err == nil && err != nil {
-				t.Errorf("Error should have been nil but was %v", err)
+			require.Equal(t, tc.Expected, tc.Query.String())
+			require.Equal(t, tc.Expected, fmt.Sprintf("%v", tc.Query))
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	ts := []string{
+		"SELECT a AS z, b FROM 'b' WHERE a = '1' OR b IN (1, 2) AND c BETWEEN 1 AND 2 AND d IS NULL ORDER BY a DESC LIMIT 5",
+		"SELECT dept, count(id) FROM 'emp' GROUP BY dept HAVING count(id) > '5'",
+		"INSERT INTO 'a' (b, c) VALUES ('1', '2'), ('3', '4')",
+		"UPDATE 'a' SET b = 'hello' WHERE a = '1'",
+		"DELETE FROM 'a' WHERE b = c",
+	}
+	for _, sql := range ts {
+		t.Run(sql, func(t *testing.T) {
+			q, err := Parse(sql)
+			require.NoError(t, err)
+			bs, err := json.Marshal(q)
+			require.NoError(t, err)
+			var unmarshalled query.Query
+			require.NoError(t, json.Unmarshal(bs, &unmarshalled))
+			require.Equal(t, q, unmarshalled, "Query didn't survive the JSON round trip")
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	q, err := Parse("SELECT a, count(b) FROM 'c' JOIN d ON c.id = d.id WHERE (e IN (1, 2) OR f = 'g') UNION SELECT a, b FROM 'h'")
+	require.NoError(t, err)
+	require.True(t, reflect.DeepEqual(q, q.Canonical()))
+
+	built := query.Query{
+		Type:        query.Insert,
+		TableName:   "a",
+		Fields:      []string{"b"},
+		Inserts:     [][]string{{"1"}},
+		InsertTypes: [][]query.OperandType{{query.OpNumber}},
+		Conditions:  []query.Condition{},
+		Updates:     map[string]string{},
+		Aliases:     map[string]string{},
+		Returning:   []string{},
+	}
+	parsed, err := Parse("INSERT INTO 'a' (b) VALUES (1)")
+	require.NoError(t, err)
+	require.True(t, reflect.DeepEqual(built.Canonical(), parsed.Canonical()))
+	require.NotNil(t, built.Conditions, "Canonical shouldn't modify the query")
+
+	canonical := q.Canonical()
+	canonical.Joins[0].On[0].Operand1 = "x"
+	canonical.WhereExpr.Children[0].Children[0].Condition.Operand2List[0] = "x"
+	require.Equal(t, "c.id", q.Joins[0].On[0].Operand1, "Canonical shouldn't share slices with the query")
+	require.Equal(t, "1", q.WhereExpr.Children[0].Children[0].Condition.Operand2List[0], "Canonical shouldn't share slices with the query")
+}
+
+func TestJSONUsesNames(t *testing.T) {
+	q, err := Parse("SELECT a FROM 'b' WHERE a >= 1 ORDER BY a DESC NULLS LAST")
+	require.NoError(t, err)
+	bs, err := json.Marshal(q)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "Select",
+		"tableName": "b",
+		"conditions": [{
+			"operand1": "a", "operand1IsField": true, "operator": "Gte",
+			"operand2": "1", "operand2IsField": false, "operand2Type": "OpNumber", "orWithNext": false, "pos": 24
+		}],
+		"fields": ["a"],
+		"fieldExprs": [{"type": "Column", "text": "a", "name": "a"}],
+		"orderBy": [{"field": "a", "direction": "Desc", "nulls": "NullsLast"}]
+	}`, string(bs))
+
+	var unknown query.Query
+	require.Error(t, json.Unmarshal([]byte(`{"type": "Merge"}`), &unknown))
+}
+
+func TestWalk(t *testing.T) {
+	visit := func(q *query.Query) []string {
+		var visited []string
+		q.Walk(func(node interface{}) bool {
+			switch n := node.(type) {
+			case *query.Query:
+				visited = append(visited, "query "+n.TableName)
+			case *query.FieldExpr:
+				visited = append(visited, "field "+n.Text)
+			case *query.Join:
+				visited = append(visited, "join "+n.TableName)
+			case *query.Condition:
+				visited = append(visited, "condition "+n.String())
+			case *query.FieldName:
+				visited = append(visited, "field name "+n.Name)
+			case *query.OrderByField:
+				visited = append(visited, "order by "+n.Field)
+			case *query.UpdatePair:
+				visited = append(visited, "assignment "+n.Field+" = "+n.Value)
+			case *query.Value:
+				visited = append(visited, "value "+n.Value)
 			}
-			if tc.Err != nil && err != nil {
-				require.Equal(t, tc.Err, err, "Unexpected error")
+			return true
+		})
+		return visited
+	}
+	ts := []struct {
+		SQL      string
+		Expected []string
+	}{
+		{
+			SQL: "SELECT a, count(b) FROM 'c' JOIN d ON c.id = d.id WHERE e = '1' GROUP BY a HAVING f > 2 ORDER BY a UNION SELECT a, b FROM 'g'",
+			Expected: []string{
+				"query c", "field a", "field count(b)", "field b", "join d", "condition c.id = d.id", "condition e = '1'",
+				"field name a", "condition f > 2", "order by a", "query g", "field a", "field b",
+			},
+		},
+		{
+			SQL:      "UPDATE 'a' SET c = c + 1, b = '1' WHERE d = 2 RETURNING b, c",
+			Expected: []string{"query a", "condition d = 2", "assignment c = c + 1", "assignment b = 1", "field name b", "field name c"},
+		},
+		{
+			SQL: "INSERT INTO 'a' (b, c) VALUES ('1', 2), (3, 'x') ON DUPLICATE KEY UPDATE c = '4', b = b RETURNING b",
+			Expected: []string{
+				"query a", "field name b", "field name c", "value 1", "value 2", "value 3", "value x", "assignment b = b",
+				"assignment c = 4", "field name b",
+			},
+		},
+		{
+			SQL:      "CALL a('1', 2)",
+			Expected: []string{"query ", "value 1", "value 2"},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, visit(&q))
+		})
+	}
+
+	q, err := Parse("SELECT a, count(b) FROM 'c' JOIN d ON c.id = d.id WHERE e = '1' GROUP BY a HAVING f > 2 ORDER BY a UNION SELECT a, b FROM 'g'")
+	require.NoError(t, err)
+	var stoppedAt []string
+	q.Walk(func(node interface{}) bool {
+		if c, ok := node.(*query.Condition); ok {
+			stoppedAt = append(stoppedAt, c.Operand1)
+			return false
+		}
+		return true
+	})
+	require.Equal(t, []string{"c.id"}, stoppedAt, "Walk didn't stop when fn returned false")
+
+	q.Walk(func(node interface{}) bool {
+		if n, ok := node.(*query.Query); ok && n.TableName == "g" {
+			n.TableName = "h"
+		}
+		return true
+	})
+	require.Equal(t, "h", q.Union.Query.TableName, "Walk didn't allow rewriting nodes")
+
+	q, err = Parse("UPDATE 'a' SET b = '1' WHERE c = 2")
+	require.NoError(t, err)
+	q.Walk(func(node interface{}) bool {
+		if n, ok := node.(*query.UpdatePair); ok {
+			n.Field, n.Value = "d", "e"
+		}
+		return true
+	})
+	require.Equal(t, []query.UpdatePair{{Field: "d", Value: "e", Type: query.OpQuoted}}, q.UpdatePairs)
+	require.Equal(t, map[string]string{"d": "e"}, q.Updates, "Walk didn't keep Updates in sync with UpdatePairs")
+	require.Equal(t, map[string]query.OperandType{"d": query.OpQuoted}, q.UpdateTypes)
+
+	q, err = Parse("INSERT INTO 'a' (b) VALUES ('1') ON DUPLICATE KEY UPDATE b = '2'")
+	require.NoError(t, err)
+	q.Walk(func(node interface{}) bool {
+		switch n := node.(type) {
+		case *query.Value:
+			n.Value, n.Type = "3", query.OpNumber
+		case *query.UpdatePair:
+			n.Field = "c"
+		}
+		return true
+	})
+	require.Equal(t, [][]string{{"3"}}, q.Inserts)
+	require.Equal(t, [][]query.OperandType{{query.OpNumber}}, q.InsertTypes)
+	require.Equal(t, map[string]string{"c": "2"}, q.OnDuplicate)
+	require.Equal(t, map[string]query.OperandType{"c": query.OpQuoted}, q.OnDuplicateTypes)
+}
+
+func TestNotEqualsOperators(t *testing.T) {
+	expected, err := Parse("SELECT a FROM 'b' WHERE a != '1'")
+	require.NoError(t, err)
+	for _, sql := range []string{"SELECT a FROM 'b' WHERE a <> '1'", "SELECT a FROM 'b' WHERE a<>'1'"} {
+		actual, err := Parse(sql)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	}
+}
+
+func TestComparisonOperators(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Operator query.Operator
+	}{
+		{SQL: "SELECT a FROM 'b' WHERE a >= '1'", Operator: query.Gte},
+		{SQL: "SELECT a FROM 'b' WHERE a>='1'", Operator: query.Gte},
+		{SQL: "SELECT a FROM 'b' WHERE (a>='1')", Operator: query.Gte},
+		{SQL: "SELECT a FROM 'b' WHERE a <= '1'", Operator: query.Lte},
+		{SQL: "SELECT a FROM 'b' WHERE a<='1'", Operator: query.Lte},
+		{SQL: "SELECT a FROM 'b' WHERE (a<='1')", Operator: query.Lte},
+		{SQL: "SELECT a FROM 'b' WHERE a > '1'", Operator: query.Gt},
+		{SQL: "SELECT a FROM 'b' WHERE a>'1'", Operator: query.Gt},
+		{SQL: "SELECT a FROM 'b' WHERE a < '1'", Operator: query.Lt},
+		{SQL: "SELECT a FROM 'b' WHERE a<'1'", Operator: query.Lt},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Len(t, q.Conditions, 1)
+			require.Equal(t, tc.Operator, q.Conditions[0].Operator)
+			require.Equal(t, "1", q.Conditions[0].Operand2)
+		})
+	}
+
+	// The two characters of an operator can't be apart
+	_, err := Parse("SELECT a FROM 'b' WHERE a > = '1'")
+	require.EqualError(t, err, "at WHERE: expected quoted value")
+}
+
+func TestOperatorsWithoutSpaces(t *testing.T) {
+	ts := []struct {
+		Unspaced string
+		Spaced   string
+	}{
+		{
+			Unspaced: "SELECT a FROM 'b' WHERE a='1' AND b!=2 AND c<3 AND d>-4 AND e<=f AND g>=h.i AND j<>'5'",
+			Spaced:   "SELECT a FROM 'b' WHERE a = '1' AND b != 2 AND c < 3 AND d > -4 AND e <= f AND g >= h.i AND j <> '5'",
+		},
+		{
+			Unspaced: "SELECT a FROM 'b' WHERE a='1'OR(b!=?)",
+			Spaced:   "SELECT a FROM 'b' WHERE a = '1' OR ( b != ? )",
+		},
+		{
+			Unspaced: "SELECT a,COUNT(*) FROM 'b' JOIN 'c' ON b.id=c.id GROUP BY a HAVING COUNT(*)>=1",
+			Spaced:   "SELECT a, COUNT(*) FROM 'b' JOIN 'c' ON b.id = c.id GROUP BY a HAVING COUNT(*) >= 1",
+		},
+		{
+			Unspaced: "UPDATE 'b' SET a='1',c=2 WHERE d<>3",
+			Spaced:   "UPDATE 'b' SET a = '1', c = 2 WHERE d <> 3",
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Unspaced, func(t *testing.T) {
+			expected, err := Parse(tc.Spaced)
+			require.NoError(t, err)
+			actual, err := Parse(tc.Unspaced)
+			require.NoError(t, err)
+			removeConditionPos(&expected)
+			removeConditionPos(&actual)
+			require.Equal(t, expected, actual)
+		})
+	}
+
+	// Arithmetic is parsed as such without spaces too, but kept as written, and hyphenated identifiers are kept whole
+	// in SELECTed fields, like in conditions
+	q, err := Parse("UPDATE 'b' SET counter=counter-1,c=d*2+e.f WHERE g=1")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"counter": "counter-1", "c": "d*2+e.f"}, q.Updates)
+	require.Equal(t, map[string]query.OperandType{"counter": query.OpExpression, "c": query.OpExpression}, q.UpdateTypes)
+
+	q, err = Parse("SELECT a-1,b/c AS d,e*2,my-col FROM 'e'")
+	require.NoError(t, err)
+	require.Equal(t, []string{"a-1", "b/c", "e*2", "my-col"}, q.Fields)
+	require.Equal(t, []query.FieldExpr{
+		{Type: query.Column, Text: "a-1", Name: "a-1"},
+		{Type: query.Arithmetic, Text: "b/c"},
+		{Type: query.Arithmetic, Text: "e*2"},
+		{Type: query.Column, Text: "my-col", Name: "my-col"},
+	}, q.FieldExprs)
+}
+
+func TestLexer(t *testing.T) {
+	lex := func(sql string) []Token {
+		l := NewLexer(sql)
+		var tokens []Token
+		for {
+			token, err := l.Next()
+			if err == io.EOF {
+				return tokens
 			}
-			if len(actual) > 0 {
-				require.Equal(t, tc.Expected, actual[0], "Query didn't match expectation")
+			require.NoError(t, err)
+			tokens = append(tokens, token)
+		}
+	}
+	tokens := lex("  select a, COUNT(*) -- comment\nFROM 'it''s' where  b>=-1.5 AND c = $1 group  by a")
+	require.Equal(t, []Token{
+		{Kind: KeywordToken, Text: "select", Value: "SELECT", Pos: 2},
+		{Kind: IdentifierToken, Text: "a", Value: "a", Pos: 9},
+		{Kind: SymbolToken, Text: ",", Value: ",", Pos: 10},
+		{Kind: IdentifierToken, Text: "COUNT(*)", Value: "COUNT(*)", Pos: 12},
+		{Kind: KeywordToken, Text: "FROM", Value: "FROM", Pos: 32},
+		{Kind: StringToken, Text: "'it''s'", Value: "it's", Pos: 37},
+		{Kind: KeywordToken, Text: "where", Value: "WHERE", Pos: 45},
+		{Kind: IdentifierToken, Text: "b", Value: "b", Pos: 52},
+		{Kind: SymbolToken, Text: ">=", Value: ">=", Pos: 53},
+		{Kind: NumberToken, Text: "-1.5", Value: "-1.5", Pos: 55},
+		{Kind: KeywordToken, Text: "AND", Value: "AND", Pos: 60},
+		{Kind: IdentifierToken, Text: "c", Value: "c", Pos: 64},
+		{Kind: SymbolToken, Text: "=", Value: "=", Pos: 66},
+		{Kind: PlaceholderToken, Text: "$1", Value: "$1", Pos: 68},
+		{Kind: KeywordToken, Text: "group  by", Value: "GROUP BY", Pos: 71},
+		{Kind: IdentifierToken, Text: "a", Value: "a", Pos: 81},
+	}, tokens)
+
+	// The semicolon and arithmetic operators, which Parse accepts too
+	ts := []struct {
+		SQL      string
+		Expected []string
+	}{
+		{
+			SQL:      "UPDATE a SET c = c + 1, d = d-e * 2 WHERE f = 1;",
+			Expected: []string{"UPDATE", "a", "SET", "c", "=", "c", "+", "1", ",", "d", "=", "d-e", "*", "2", "WHERE", "f", "=", "1", ";"},
+		},
+		{
+			SQL:      "SELECT a/2, b - 1, * FROM c;",
+			Expected: []string{"SELECT", "a", "/", "2", ",", "b", "-", "1", ",", "*", "FROM", "c", ";"},
+		},
+	}
+	for _, tc := range ts {
+		_, err := Parse(tc.SQL)
+		require.NoError(t, err)
+		var values []string
+		for _, token := range lex(tc.SQL) {
+			values = append(values, token.Value)
+			if strings.Contains(";+-*/", token.Value) && token.Value != "*" {
+				require.Equal(t, SymbolToken, token.Kind, "Unexpected kind for %s", token.Value)
 			}
+		}
+		require.Equal(t, tc.Expected, values)
+	}
+
+	l := NewLexer("SELECT 'unclosed")
+	_, err := l.Next()
+	require.NoError(t, err)
+	_, err = l.Next()
+	posErr, ok := err.(*ErrorWithPos)
+	require.True(t, ok, "Expected an *ErrorWithPos, but got %v", err)
+	require.Equal(t, 7, posErr.Pos())
+}
+
+func TestConditionPos(t *testing.T) {
+	sql := "\n  SELECT a FROM 'b' JOIN c ON b.id = c.id\n  WHERE d = 1 AND NOT (e > 2 OR f IN (1))\n  UNION SELECT a FROM g WHERE h = 'i'"
+	q, err := Parse(sql)
+	require.NoError(t, err)
+	var positions []int
+	q.Walk(func(node interface{}) bool {
+		if c, ok := node.(*query.Condition); ok {
+			require.True(t, strings.HasPrefix(sql[c.Pos:], c.Operand1), "Expected %s at %d", c.Operand1, c.Pos)
+			positions = append(positions, c.Pos)
+		}
+		return true
+	})
+	require.Equal(t, []int{31, 51, 66, 75, 115}, positions)
+	require.Equal(t, 66, q.WhereExpr.Children[1].Children[0].Children[0].Condition.Pos)
+}
+
+func TestShapeHelpers(t *testing.T) {
+	q, err := Parse("SELECT a FROM 'b' JOIN 'c' ON b.id = c.id WHERE d = 1 AND e = 2 GROUP BY a HAVING COUNT(*) > 1")
+	require.NoError(t, err)
+	require.True(t, q.HasWhere())
+	require.True(t, q.HasJoins())
+	require.Equal(t, 2, q.ConditionCount())
+
+	q, err = Parse("SELECT a FROM 'b'")
+	require.NoError(t, err)
+	require.False(t, q.HasWhere())
+	require.False(t, q.HasJoins())
+	require.Equal(t, 0, q.ConditionCount())
+}
+
+func TestReferencedColumns(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected []string
+	}{
+		{
+			SQL:      "SELECT a, u.b AS c, round(avg(d), 2), * FROM 'users' AS u WHERE e = f AND g > '1' GROUP BY h HAVING COUNT(*) > 1 ORDER BY i, 1",
+			Expected: []string{"a", "d", "e", "f", "g", "h", "i", "u.b"},
+		},
+		{
+			SQL:      "SELECT a FROM 'b' JOIN c ON b.id = c.b_id UNION SELECT d FROM 'e'",
+			Expected: []string{"a", "b.id", "c.b_id", "d"},
+		},
+		{
+			SQL:      "UPDATE 'a' SET b = '1', c = c + 1 WHERE d = '2'",
+			Expected: []string{"b", "c", "d"},
+		},
+		{
+			SQL:      "INSERT INTO 'a' (b, c) SELECT d, e FROM 'f'",
+			Expected: []string{"b", "c", "d", "e"},
+		},
+		{
+			SQL:      "SELECT '1', 2 FROM 'a'",
+			Expected: []string{},
+		},
+		{
+			SQL:      "SELECT a * b, c FROM 'd' WHERE lower(e) = 'x.y' GROUP BY f HAVING count(DISTINCT g) > 1 AND max(h.i) < 2",
+			Expected: []string{"a", "b", "c", "e", "f", "g", "h.i"},
+		},
+		{
+			SQL:      "UPDATE 'a' SET b = c + 1, d = e WHERE coalesce(f, 'g', ?) = 1 RETURNING h",
+			Expected: []string{"b", "c", "d", "e", "f", "h"},
+		},
+		{
+			SQL:      "INSERT INTO 'a' (b) VALUES ('1') ON DUPLICATE KEY UPDATE c = c + d",
+			Expected: []string{"b", "c", "d"},
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, q.ReferencedColumns())
+		})
+	}
+}
+
+func TestRenameTable(t *testing.T) {
+	ts := []struct {
+		SQL      string
+		Expected string
+	}{
+		{
+			SQL:      "SELECT users.id, users.name AS n, 'users.x' FROM 'users' WHERE users.id = '1' AND users.a = users.b ORDER BY users.id",
+			Expected: "SELECT accounts.id, accounts.name AS n, 'users.x' FROM 'accounts' WHERE accounts.id = '1' AND accounts.a = accounts.b ORDER BY accounts.id",
+		},
+		{
+			SQL:      "SELECT a FROM 'b' JOIN users ON b.id = users.b_id WHERE users.c IN (SELECT d FROM 'users') GROUP BY users.e",
+			Expected: "SELECT a FROM 'b' INNER JOIN 'accounts' ON b.id = accounts.b_id WHERE accounts.c IN (SELECT d FROM 'accounts') GROUP BY accounts.e",
+		},
+		{
+			SQL:      "SELECT u.id, count(users.id) FROM 'users' AS u WHERE u.a = '1'",
+			Expected: "SELECT u.id, count(users.id) FROM 'accounts' AS u WHERE u.a = '1'",
+		},
+		{
+			SQL:      "DELETE FROM 'a' USING users WHERE a.id = users.a_id",
+			Expected: "DELETE FROM 'a' USING 'accounts' WHERE a.id = accounts.a_id",
+		},
+		{
+			SQL:      "SELECT usersx.id FROM 'usersx' WHERE x.users = '1'",
+			Expected: "SELECT usersx.id FROM 'usersx' WHERE x.users = '1'",
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.SQL, func(t *testing.T) {
+			q, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			q.RenameTable("users", "accounts")
+			require.Equal(t, tc.Expected, q.String())
+		})
+	}
+
+	q, err := Parse("INSERT INTO 'users' (a) VALUES ('1') ON DUPLICATE KEY UPDATE users.a = users.b, c = '2'")
+	require.NoError(t, err)
+	q.RenameTable("users", "accounts")
+	require.Equal(t, map[string]string{"accounts.a": "accounts.b", "c": "2"}, q.OnDuplicate)
+	require.Equal(t, map[string]query.OperandType{"accounts.a": query.OpField, "c": query.OpQuoted}, q.OnDuplicateTypes)
+
+	built := query.Query{Type: query.Update, TableName: "users", Updates: map[string]string{"users.a": "1"}}
+	built.RenameTable("users", "accounts")
+	require.Equal(t, map[string]string{"accounts.a": "1"}, built.Updates)
+	require.Nil(t, built.UpdateTypes)
+
+	// Renaming into a prefix of the old name mustn't rename anything twice, however the maps happen to be iterated
+	for i := 0; i < 20; i++ {
+		q, err := Parse("UPDATE users SET users.f = 1, users.g = users.h, users.i = 'j', k = 2 WHERE (users.id = 1 OR users.id = 2)")
+		require.NoError(t, err)
+		q.RenameTable("users", "public.users")
+		require.Equal(t, "UPDATE public.users SET public.users.f = 1, public.users.g = public.users.h, public.users.i = 'j', k = 2 WHERE (public.users.id = 1 OR public.users.id = 2)", q.String())
+		require.Equal(t, "public", q.Schema)
+		require.Equal(t, "users", q.TableName)
+		for _, u := range q.UpdatePairs {
+			require.Equal(t, u.Value, q.Updates[u.Field], "Updates isn't in sync with UpdatePairs")
+			require.Equal(t, u.Type, q.UpdateTypes[u.Field], "UpdateTypes isn't in sync with UpdatePairs")
+		}
+		require.Len(t, q.Updates, len(q.UpdatePairs))
+	}
+}
+
+func TestParseOfType(t *testing.T) {
+	q, err := ParseSelect("SELECT a FROM 'b'")
+	require.NoError(t, err)
+	require.Equal(t, query.Select, q.Type)
+	q, err = ParseInsert("INSERT INTO 'a' (b) VALUES ('1')")
+	require.NoError(t, err)
+	require.Equal(t, query.Insert, q.Type)
+	q, err = ParseUpdate("UPDATE 'a' SET b = '1' WHERE c = '2'")
+	require.NoError(t, err)
+	require.Equal(t, query.Update, q.Type)
+	q, err = ParseDelete("DELETE FROM 'a' WHERE b = '1'")
+	require.NoError(t, err)
+	require.Equal(t, query.Delete, q.Type)
+
+	q, err = ParseSelect("DELETE FROM 'a' WHERE b = '1'")
+	require.EqualError(t, err, "expected query type Select, but got Delete")
+	require.Equal(t, query.Query{}, q)
+	_, err = ParseUpdate("INSERT INTO 'a' (b) VALUES ('1')")
+	require.EqualError(t, err, "expected query type Update, but got Insert")
+	_, err = ParseDelete("SELECT FROM 'a'")
+	require.EqualError(t, err, "at SELECT: expected field to SELECT")
+}
+
+func TestIsNumber(t *testing.T) {
+	ts := []struct {
+		S        string
+		Expected bool
+	}{
+		{"0", true},
+		{"42", true},
+		{"-42", true},
+		{"+42", true},
+		{"3.14", true},
+		{"-3.14", true},
+		{".5", true},
+		{"-.5", true},
+		{"1.", true},
+		{"", false},
+		{"-", false},
+		{".", false},
+		{"-.", false},
+		{"--1", false},
+		{"+-1", false},
+		{"1.2.3", false},
+		{"1-2", false},
+		{"1a", false},
+		{"a1", false},
+		{" 1", false},
+		{"1e10", true},
+		{"1.5e10", true},
+		{"2E-3", true},
+		{"-.5e+3", true},
+		{"1e", false},
+		{"1e-", false},
+		{"1e1.5", false},
+		{"1e--1", false},
+		{"1e2e3", false},
+		{"e5", false},
+	}
+	for _, tc := range ts {
+		t.Run(tc.S, func(t *testing.T) {
+			require.Equal(t, tc.Expected, isNumber(tc.S))
+		})
+	}
+}
+
+func TestOperatorString(t *testing.T) {
+	for i, name := range query.OperatorString {
+		o := query.Operator(i)
+		require.NotEmpty(t, o.String(), "Operator %s has no String()", name)
+		if o != query.UnknownOperator {
+			q, err := Parse("SELECT a FROM 'b' WHERE " + query.Condition{Operand1: "c", Operator: o, Operand2: "1", Operand2Type: query.OpQuoted, Operand2List: []string{"1"}, Operand3: "2", Operand3Type: query.OpNumber, Subquery: &query.Query{Type: query.Select, Fields: []string{"1"}, TableName: "d"}}.String())
+			require.NoError(t, err)
+			require.Equal(t, o, q.Conditions[0].Operator, "Operator %s's String() doesn't parse back into it", name)
+		}
+	}
+	require.Equal(t, ">=", query.Gte.String())
+	require.Equal(t, "NOT IN", query.NotIn.String())
+	require.Equal(t, "UnknownOperator", query.UnknownOperator.String())
+	require.Equal(t, "Operator(100)", query.Operator(100).String())
+	require.True(t, query.IsNull.IsUnary())
+	require.True(t, query.IsNotNull.IsUnary())
+	require.False(t, query.Eq.IsUnary())
+}
+
+func TestRegisterReservedWords(t *testing.T) {
+	defaultReservedWords := reservedWords
+	defer func() {
+		reservedWords = defaultReservedWords
+		reservedWordsByFirstByte = groupByFirstByte(reservedWords)
+	}()
+
+	_, err := Parse("SELECT qualify, a FROM 'b'")
+	require.NoError(t, err)
+
+	RegisterReservedWords("qualify", "  Describe   Extended ", "SELECT", "")
+	require.Len(t, reservedWords, len(defaultReservedWords)+2)
+
+	_, err = Parse("SELECT qualify, a FROM 'b'")
+	require.EqualError(t, err, "at SELECT: expected field to SELECT")
+	q, err := Parse("DESCRIBE users")
+	require.NoError(t, err, "Reserved words that a registered one starts with must still work")
+	require.Equal(t, query.Query{Type: query.Describe, TableName: "users"}, q)
+
+	l := NewLexer("describe\n extended users QUALIFY")
+	var tokens []Token
+	for token, err := l.Next(); err != io.EOF; token, err = l.Next() {
+		require.NoError(t, err)
+		tokens = append(tokens, token)
+	}
+	require.Equal(t, []Token{
+		{Kind: KeywordToken, Text: "describe\n extended", Value: "DESCRIBE EXTENDED", Pos: 0},
+		{Kind: IdentifierToken, Text: "users", Value: "users", Pos: 19},
+		{Kind: KeywordToken, Text: "QUALIFY", Value: "QUALIFY", Pos: 25},
+	}, tokens)
+}
+
+func TestParseWithOptions(t *testing.T) {
+	ts := []struct {
+		Name     string
+		SQL      string
+		Options  Options
+		Expected *query.Query // If nil, the query is expected to parse like with Parse
+		Err      error
+	}{
+		{
+			Name: "duplicate fields are allowed by default",
+			SQL:  "SELECT a, a FROM 'b'",
+		},
+		{
+			Name:    "duplicate fields fail with RejectDuplicateFields",
+			SQL:     "SELECT a, b, a FROM 'b'",
+			Options: Options{RejectDuplicateFields: true},
+			Err:     fmt.Errorf("at SELECT: duplicate field a"),
+		},
+		{
+			Name:    "distinct fields work with RejectDuplicateFields",
+			SQL:     "SELECT a, b, a + 1 FROM 'b'",
+			Options: Options{RejectDuplicateFields: true},
+		},
+		{
+			Name:    "duplicate fields in a nested SELECT fail with RejectDuplicateFields",
+			SQL:     "INSERT INTO 'a' (b, c) SELECT d, d FROM 'e'",
+			Options: Options{RejectDuplicateFields: true},
+			Err:     fmt.Errorf("at SELECT: duplicate field d"),
+		},
+		{
+			Name:    "identifiers quoted with double quotes work with IdentifierQuotes",
+			SQL:     `SELECT "order", a FROM "select" WHERE "order" = '1'`,
+			Options: Options{IdentifierQuotes: `"`},
+			Expected: &query.Query{
+				Type:      query.Select,
+				TableName: "select",
+				Fields:    []string{`"order"`, "a"},
+				Conditions: []query.Condition{
+					{Operand1: `"order"`, Operand1IsField: true, Operator: query.Eq, Operand2: "1", Operand2IsField: false, Operand2Type: query.OpQuoted},
+				},
+			},
+		},
+		{
+			Name:    "identifiers quoted with backticks work with IdentifierQuotes",
+			SQL:     "UPDATE `my schema`.`my table` SET `from` = 1 WHERE `to` = 2",
+			Options: Options{IdentifierQuotes: "`"},
+			Expected: &query.Query{
+				Type:        query.Update,
+				Schema:      "my schema",
+				TableName:   "my table",
+				Updates:     map[string]string{"`from`": "1"},
+				UpdateTypes: map[string]query.OperandType{"`from`": query.OpNumber},
+				UpdatePairs: []query.UpdatePair{{Field: "`from`", Value: "1", Type: query.OpNumber}},
+				Conditions: []query.Condition{
+					{Operand1: "`to`", Operand1IsField: true, Operator: query.Eq, Operand2: "2", Operand2IsField: false, Operand2Type: query.OpNumber},
+				},
+			},
+		},
+		{
+			Name: "identifiers quoted with double quotes fail by default",
+			SQL:  `SELECT "a" FROM 'b'`,
+			Err:  fmt.Errorf("at SELECT: expected field to SELECT"),
+		},
+		{
+			Name:    "unclosed quoted table names fail with IdentifierQuotes",
+			SQL:     `SELECT a FROM "b`,
+			Options: Options{IdentifierQuotes: `"`},
+			Err:     fmt.Errorf("at SELECT: expected table name"),
+		},
+		{
+			Name:    "unquoted table names fail with RejectUnquotedTableNames",
+			SQL:     "SELECT a FROM b",
+			Options: Options{RejectUnquotedTableNames: true},
+			Err:     fmt.Errorf("at SELECT: expected quoted table name"),
+		},
+		{
+			Name:    "quoted table names work with RejectUnquotedTableNames",
+			SQL:     "SELECT a FROM 'b' JOIN \"c\" ON b.id = c.id",
+			Options: Options{RejectUnquotedTableNames: true, IdentifierQuotes: `"`},
+			Expected: &query.Query{
+				Type:      query.Select,
+				TableName: "b",
+				Fields:    []string{"a"},
+				Joins: []query.Join{{Type: query.Inner, TableName: "c", On: []query.Condition{
+					{Operand1: "b.id", Operand1IsField: true, Operator: query.Eq, Operand2: "c.id", Operand2IsField: true, Operand2Type: query.OpField},
+				}}},
+			},
+		},
+		{
+			Name:    "a missing semicolon fails with SemicolonRequired",
+			SQL:     "SELECT a FROM 'b'",
+			Options: Options{Semicolon: SemicolonRequired},
+			Err:     fmt.Errorf("expected semicolon at the end of the query"),
+		},
+		{
+			Name:    "a semicolon works with SemicolonRequired",
+			SQL:     "SELECT a FROM 'b';",
+			Options: Options{Semicolon: SemicolonRequired},
+		},
+		{
+			Name:    "a semicolon fails with SemicolonForbidden",
+			SQL:     "SELECT a FROM 'b' ; ",
+			Options: Options{Semicolon: SemicolonForbidden},
+			Err:     fmt.Errorf("unexpected semicolon at the end of the query"),
+		},
+		{
+			Name:    "a semicolon followed by a comment works with SemicolonRequired",
+			SQL:     "SELECT a FROM 'b'; -- comment",
+			Options: Options{Semicolon: SemicolonRequired},
+		},
+		{
+			Name:    "known functions work with Functions",
+			SQL:     "SELECT COUNT(*), round(avg(a), 2), b FROM 'c'",
+			Options: Options{Functions: []string{"count", "ROUND", "avg"}},
+		},
+		{
+			Name:    "unknown functions fail with Functions",
+			SQL:     "SELECT count(*), cuont(a) FROM 'c'",
+			Options: Options{Functions: []string{"count"}},
+			Err:     fmt.Errorf("at SELECT: unknown function cuont"),
+		},
+		{
+			Name:    "unknown functions within arguments fail with Functions",
+			SQL:     "SELECT round(avg(a), 2) FROM 'c'",
+			Options: Options{Functions: []string{"round"}},
+			Err:     fmt.Errorf("at SELECT: unknown function avg"),
+		},
+	}
+	for _, tc := range ts {
+		t.Run(tc.Name, func(t *testing.T) {
+			q, err := ParseWithOptions(tc.SQL, tc.Options)
 			if tc.Err != nil {
-				output.ErrorExamples = append(output.ErrorExamples, tc)
-			} else {
-				output.NoErrorExamples = append(output.NoErrorExamples, tc)
+				require.EqualError(t, err, tc.Err.Error())
+				require.Equal(t, query.Query{}, q)
+				return
+			}
+			require.NoError(t, err)
+			if tc.Expected != nil {
+				removeFieldExprs(&q)
+				removeConditionPos(&q)
+				require.Equal(t, *tc.Expected, q)
+				return
 			}
+			expected, err := Parse(tc.SQL)
+			require.NoError(t, err)
+			require.Equal(t, expected, q)
 		})
 	}
-	createReadme(output)
 }
 
-func createReadme(out output) {
-	content, err := ioutil.ReadFile("README.template")
-	if err != nil {
-		log.Fatal(err)
+var benchmarkQueries = []string{
+	"SELECT a, b AS c, COUNT(*) FROM 'd' JOIN 'e' ON d.id = e.d_id WHERE f = 'g' AND h >= 1.5 OR i IN ('j', 'k') GROUP BY a, b ORDER BY c DESC LIMIT 10",
+	"INSERT INTO 'a' (b, c, d) VALUES ('1', 2, NULL), ('3', 4, NULL), ('5', 6, NULL)",
+	"UPDATE 'a' SET b = 'c', d = 1 WHERE e = 'f' AND g IS NOT NULL",
+	"DELETE FROM 'a' WHERE b BETWEEN 1 AND 10 AND c LIKE 'd%'",
+}
+
+func BenchmarkParse(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, sql := range benchmarkQueries {
+			if _, err := Parse(sql); err != nil {
+				b.Fatal(err)
+			}
+		}
 	}
-	t := template.Must(template.New("").Parse(string(content)))
-	f, err := os.Create("README.md")
-	if err != nil {
-		log.Fatal(err)
+}
+
+func BenchmarkLexer(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, sql := range benchmarkQueries {
+			l := NewLexer(sql)
+			for {
+				if _, err := l.Next(); err == io.EOF {
+					break
+				} else if err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
 	}
-	if err := t.Execute(f, out); err != nil {
-		log.Fatal(err)
+}
+
+func BenchmarkParseLargeInsert(b *testing.B) {
+	rows := make([]string, 1000)
+	for i := range rows {
+		rows[i] = fmt.Sprintf("(%d, 'some quoted payload that is never compared case-insensitively #%d', NULL)", i, i)
+	}
+	sql := "INSERT INTO 'a' (b, c, d) VALUES " + strings.Join(rows, ", ")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(sql); err != nil {
+			b.Fatal(err)
+		}
 	}
 }